@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// lookupHost resolves a host name to IP addresses; replaced in tests.
+var lookupHost = net.DefaultResolver.LookupHost
+
+// runBackends resolves the DNS name of g.Address and runs cmd against
+// every backend IP separately, keeping the original authority. Backends
+// are grouped by schema fingerprint, largest group first, and the output
+// of the first backend of each group is printed under a
+// "==> schema <fingerprint>: <backends> <==" header, followed by the
+// failed backends. Several schemas are reported as an error.
+func runBackends(cmd runner, g globals, concurrency int) error {
+	host, port, err := splitDNSTarget(g.Address)
+	if err != nil {
+		return err
+	}
+	ips, err := lookupHost(context.Background(), host)
+	if err != nil {
+		return errors.Wrapf(err, "cannot resolve %s", host)
+	}
+	sort.Strings(ips)
+	backends := make([]string, len(ips))
+	for i, ip := range ips {
+		backends[i] = net.JoinHostPort(ip, port)
+	}
+	authority := net.JoinHostPort(host, port)
+	results := runConcurrently(backends, concurrency, func(backend string, r *targetResult) {
+		tg := g
+		tg.Address = backend
+		tg.authority = authority
+		tg.out = &r.out
+		if r.err = tg.setResolver(); r.err != nil {
+			return
+		}
+		defer tg.closeResolver()
+		if r.err = cmd.Run(tg); r.err != nil {
+			return
+		}
+		s, err := fetchSchema(tg)
+		if err != nil {
+			r.err = errors.Wrap(err, "cannot fingerprint schema")
+			return
+		}
+		r.fingerprint = s.fingerprint()
+	})
+
+	groups := map[string][]string{}
+	var fingerprints []string
+	first := map[string]*targetResult{}
+	failed := 0
+	for i := range results {
+		r := &results[i]
+		if r.err != nil {
+			failed++
+			continue
+		}
+		if _, ok := groups[r.fingerprint]; !ok {
+			fingerprints = append(fingerprints, r.fingerprint)
+			first[r.fingerprint] = r
+		}
+		groups[r.fingerprint] = append(groups[r.fingerprint], backends[i])
+	}
+	sort.SliceStable(fingerprints, func(i, j int) bool {
+		return len(groups[fingerprints[i]]) > len(groups[fingerprints[j]])
+	})
+	for _, fp := range fingerprints {
+		header := fmt.Sprintf("schema %s: %s", fp[:12], strings.Join(groups[fp], ", "))
+		if err := printResult(g.out, header, first[fp]); err != nil {
+			return err
+		}
+	}
+	for i := range results {
+		if results[i].err == nil {
+			continue
+		}
+		if err := printResult(g.out, backends[i], &results[i]); err != nil {
+			return err
+		}
+	}
+	switch {
+	case failed > 0:
+		return errors.Errorf("%d of %d backends failed", failed, len(backends))
+	case len(fingerprints) > 1:
+		return errors.Errorf("%d different schemas across %d backends", len(fingerprints), len(backends))
+	}
+	return nil
+}
+
+// splitDNSTarget returns host and port of a gRPC target such as
+// dns:///example.com:443 or example.com:443. The port defaults to 443.
+func splitDNSTarget(target string) (string, string, error) {
+	if strings.HasPrefix(target, "dns:") {
+		rest := strings.TrimPrefix(target, "dns:")
+		if strings.HasPrefix(rest, "//") {
+			parts := strings.SplitN(rest[2:], "/", 2)
+			if len(parts) != 2 || parts[0] != "" {
+				return "", "", errors.Errorf("cannot use DNS authority of %s", target)
+			}
+			rest = parts[1]
+		}
+		target = rest
+	}
+	if target == "" {
+		return "", "", errors.New("--each-backend requires an address")
+	}
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return target, "443", nil
+	}
+	return host, port, nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+type cacheCmd struct {
+	Ls    cacheLsCmd    `cmd:"" help:"List cached file descriptors"`
+	Clear cacheClearCmd `cmd:"" help:"Remove cached file descriptors"`
+}
+
+type cacheLsCmd struct{}
+
+type cacheClearCmd struct {
+	Target string `arg:"" optional:"" help:"only remove files cached for this server address"`
+}
+
+// descriptorCache stores serialized FileDescriptorProtos fetched from a
+// reflection server on disk. Files are kept under
+// <dir>/<target>/files/<file name> and the files containing symbols and
+// extensions are recorded under <dir>/<target>/index/<key>, with all path
+// elements escaped. Entries older than ttl are ignored.
+type descriptorCache struct {
+	dir string // directory of a single target
+	ttl time.Duration
+}
+
+// cachingFetcher reads through the descriptor cache and stores what it
+// fetches.
+type cachingFetcher struct {
+	fetcher
+	cache descriptorCache
+}
+
+func (g globals) cacheDir() (string, error) {
+	if g.CacheDir != "" {
+		return g.CacheDir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "cannot determine cache directory")
+	}
+	return filepath.Join(dir, "reflect"), nil
+}
+
+// withCache wraps f in a cachingFetcher if the cache is enabled.
+func withCache(f fetcher, g globals) fetcher {
+	if !g.Cache || g.Address == "" {
+		return f
+	}
+	dir, err := g.cacheDir()
+	if err != nil {
+		return f
+	}
+	dir = filepath.Join(dir, escape(g.Address))
+	return &cachingFetcher{fetcher: f, cache: descriptorCache{dir: dir, ttl: g.CacheTTL}}
+}
+
+func (c *cachingFetcher) fileByFilename(filename string) ([]*dpb.FileDescriptorProto, error) {
+	if fdp, ok := c.cache.file(filename); ok {
+		return []*dpb.FileDescriptorProto{fdp}, nil
+	}
+	fdps, err := c.fetcher.fileByFilename(filename)
+	return c.store("", fdps, err)
+}
+
+func (c *cachingFetcher) fileContainingSymbol(symbol string) ([]*dpb.FileDescriptorProto, error) {
+	key := "symbol:" + symbol
+	if fdp, ok := c.cache.indexed(key); ok {
+		return []*dpb.FileDescriptorProto{fdp}, nil
+	}
+	fdps, err := c.fetcher.fileContainingSymbol(symbol)
+	return c.store(key, fdps, err)
+}
+
+func (c *cachingFetcher) fileContainingExtension(typ string, number int32) ([]*dpb.FileDescriptorProto, error) {
+	key := "extension:" + typ + ":" + strconv.Itoa(int(number))
+	if fdp, ok := c.cache.indexed(key); ok {
+		return []*dpb.FileDescriptorProto{fdp}, nil
+	}
+	fdps, err := c.fetcher.fileContainingExtension(typ, number)
+	return c.store(key, fdps, err)
+}
+
+// store caches the fetched files and, if key is set, records the first
+// file, which contains the requested symbol or extension, in the index.
+// Cache write errors are ignored as the cache is only an optimization.
+func (c *cachingFetcher) store(key string, fdps []*dpb.FileDescriptorProto, err error) ([]*dpb.FileDescriptorProto, error) {
+	if err != nil {
+		return nil, err
+	}
+	for i, fdp := range fdps {
+		b, err := proto.Marshal(fdp)
+		if err != nil {
+			continue
+		}
+		_ = c.cache.write(filepath.Join("files", escape(fdp.GetName())), b)
+		if i == 0 && key != "" {
+			_ = c.cache.write(filepath.Join("index", escape(key)), []byte(fdp.GetName()))
+		}
+	}
+	return fdps, nil
+}
+
+// file returns the cached file with the given name. Undecodable files
+// are treated as missing.
+func (c descriptorCache) file(filename string) (*dpb.FileDescriptorProto, bool) {
+	b, ok := c.read(filepath.Join("files", escape(filename)))
+	if !ok {
+		return nil, false
+	}
+	fdp := &dpb.FileDescriptorProto{}
+	if proto.Unmarshal(b, fdp) != nil {
+		return nil, false
+	}
+	return fdp, true
+}
+
+func (c descriptorCache) indexed(key string) (*dpb.FileDescriptorProto, bool) {
+	filename, ok := c.read(filepath.Join("index", escape(key)))
+	if !ok {
+		return nil, false
+	}
+	return c.file(string(filename))
+}
+
+func (c descriptorCache) read(name string) ([]byte, bool) {
+	fname := filepath.Join(c.dir, name)
+	fi, err := os.Stat(fname)
+	if err != nil || time.Since(fi.ModTime()) > c.ttl {
+		return nil, false
+	}
+	b, err := os.ReadFile(fname)
+	return b, err == nil
+}
+
+func (c descriptorCache) write(name string, b []byte) error {
+	fname := filepath.Join(c.dir, name)
+	if err := os.MkdirAll(filepath.Dir(fname), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(fname, b, 0o600)
+}
+
+func (c *cacheLsCmd) Run(g globals) error {
+	dir, err := g.cacheDir()
+	if err != nil {
+		return err
+	}
+	targets, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "cannot read cache directory")
+	}
+	var rows [][]string
+	for _, target := range targets {
+		files, _ := os.ReadDir(filepath.Join(dir, target.Name(), "files"))
+		for _, f := range files {
+			fi, err := f.Info()
+			if err != nil {
+				continue
+			}
+			state := "fresh"
+			if time.Since(fi.ModTime()) > g.CacheTTL {
+				state = "expired"
+			}
+			rows = append(rows, []string{unescape(target.Name()), unescape(f.Name()), fi.ModTime().Format(time.RFC3339), state})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i][0] != rows[j][0] {
+			return rows[i][0] < rows[j][0]
+		}
+		return rows[i][1] < rows[j][1]
+	})
+	headers := []string{"TARGET", "FILE", "CACHED", "STATE"}
+	if g.NoHeaders {
+		headers = nil
+	}
+	return printRows(g.out, headers, rows)
+}
+
+func (c *cacheClearCmd) Run(g globals) error {
+	dir, err := g.cacheDir()
+	if err != nil {
+		return err
+	}
+	if c.Target != "" {
+		dir = filepath.Join(dir, escape(c.Target))
+	}
+	return errors.Wrap(os.RemoveAll(dir), "cannot clear cache")
+}
+
+// escape escapes s for use as a single path element. Unlike
+// url.PathEscape it also escapes "." and "..", so that s never refers to
+// the current or parent directory.
+func escape(s string) string {
+	if s == "." || s == ".." {
+		return strings.ReplaceAll(s, ".", "%2E")
+	}
+	return url.PathEscape(s)
+}
+
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strconv"
+
+	"github.com/pkg/errors"
+	channelzpb "google.golang.org/grpc/channelz/grpc_channelz_v1"
+)
+
+type channelzCmd struct {
+	Servers  channelzServersCmd  `cmd:"" help:"List servers with call counts and listen sockets"`
+	Channels channelzChannelsCmd `cmd:"" help:"List top level channels with target, state and call counts"`
+	Sockets  channelzSocketsCmd  `cmd:"" help:"Show the connections of a server"`
+}
+
+type channelzServersCmd struct{}
+
+type channelzChannelsCmd struct{}
+
+type channelzSocketsCmd struct {
+	ServerID int64 `arg:"" name:"server-id" help:"server id as listed by channelz servers"`
+}
+
+// Run fetches all pages of servers and prints them as a single
+// GetServersResponse.
+func (c *channelzServersCmd) Run(g globals) error {
+	client, closeConn, err := newChannelzClient(g)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	all := &channelzpb.GetServersResponse{End: true}
+	req := &channelzpb.GetServersRequest{}
+	for {
+		resp, err := client.GetServers(context.Background(), req)
+		if err != nil {
+			return errors.Wrap(err, "cannot get channelz servers")
+		}
+		all.Server = append(all.Server, resp.GetServer()...)
+		if resp.GetEnd() || len(resp.GetServer()) == 0 {
+			break
+		}
+		req.StartServerId = lastServerID(resp.GetServer()) + 1
+	}
+	return printProto(all, g)
+}
+
+// Run fetches all pages of top channels and prints them as a single
+// GetTopChannelsResponse.
+func (c *channelzChannelsCmd) Run(g globals) error {
+	client, closeConn, err := newChannelzClient(g)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	all := &channelzpb.GetTopChannelsResponse{End: true}
+	req := &channelzpb.GetTopChannelsRequest{}
+	for {
+		resp, err := client.GetTopChannels(context.Background(), req)
+		if err != nil {
+			return errors.Wrap(err, "cannot get channelz channels")
+		}
+		all.Channel = append(all.Channel, resp.GetChannel()...)
+		if resp.GetEnd() || len(resp.GetChannel()) == 0 {
+			break
+		}
+		req.StartChannelId = lastChannelID(resp.GetChannel()) + 1
+	}
+	return printProto(all, g)
+}
+
+// Run fetches the sockets of the server and prints the details of each.
+// The table format prints all sockets in one table, the other formats
+// print one GetSocketResponse per socket.
+func (c *channelzSocketsCmd) Run(g globals) error {
+	client, closeConn, err := newChannelzClient(g)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	var refs []*channelzpb.SocketRef
+	req := &channelzpb.GetServerSocketsRequest{ServerId: c.ServerID}
+	for {
+		resp, err := client.GetServerSockets(context.Background(), req)
+		if err != nil {
+			return errors.Wrapf(err, "cannot get channelz sockets of server %d", c.ServerID)
+		}
+		refs = append(refs, resp.GetSocketRef()...)
+		if resp.GetEnd() || len(resp.GetSocketRef()) == 0 {
+			break
+		}
+		req.StartSocketId = refs[len(refs)-1].GetSocketId() + 1
+	}
+	sockets := make([]*channelzpb.GetSocketResponse, 0, len(refs))
+	for _, ref := range refs {
+		resp, err := client.GetSocket(context.Background(), &channelzpb.GetSocketRequest{SocketId: ref.GetSocketId()})
+		if err != nil {
+			return errors.Wrapf(err, "cannot get channelz socket %d", ref.GetSocketId())
+		}
+		sockets = append(sockets, resp)
+	}
+	if g.Format == "table" && g.Template == "" && g.Field == "" {
+		headers, rows := socketTable(sockets)
+		if g.NoHeaders {
+			headers = nil
+		}
+		return printRows(g.out, headers, rows)
+	}
+	for _, s := range sockets {
+		if err := printProto(s, g); err != nil {
+			return err
+		}
+		if g.Template == "" && g.Field == "" {
+			if err := endMessage(g); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newChannelzClient(g globals) (channelzpb.ChannelzClient, func(), error) {
+	conn, err := dial(g)
+	if err != nil {
+		return nil, nil, err
+	}
+	return channelzpb.NewChannelzClient(conn), func() { conn.Close() }, nil
+}
+
+func lastServerID(servers []*channelzpb.Server) int64 {
+	return servers[len(servers)-1].GetRef().GetServerId()
+}
+
+func lastChannelID(channels []*channelzpb.Channel) int64 {
+	return channels[len(channels)-1].GetRef().GetChannelId()
+}
+
+func channelzServerTable(resp *channelzpb.GetServersResponse) ([]string, [][]string) {
+	headers := []string{"ID", "CALLS", "SUCCEEDED", "FAILED", "LISTEN_SOCKETS"}
+	rows := make([][]string, 0, len(resp.GetServer()))
+	for _, s := range resp.GetServer() {
+		d := s.GetData()
+		rows = append(rows, []string{
+			strconv.FormatInt(s.GetRef().GetServerId(), 10),
+			strconv.FormatInt(d.GetCallsStarted(), 10),
+			strconv.FormatInt(d.GetCallsSucceeded(), 10),
+			strconv.FormatInt(d.GetCallsFailed(), 10),
+			strconv.Itoa(len(s.GetListenSocket())),
+		})
+	}
+	return headers, rows
+}
+
+func channelzChannelTable(resp *channelzpb.GetTopChannelsResponse) ([]string, [][]string) {
+	headers := []string{"ID", "TARGET", "STATE", "CALLS", "SUCCEEDED", "FAILED"}
+	rows := make([][]string, 0, len(resp.GetChannel()))
+	for _, c := range resp.GetChannel() {
+		d := c.GetData()
+		rows = append(rows, []string{
+			strconv.FormatInt(c.GetRef().GetChannelId(), 10),
+			d.GetTarget(),
+			d.GetState().GetState().String(),
+			strconv.FormatInt(d.GetCallsStarted(), 10),
+			strconv.FormatInt(d.GetCallsSucceeded(), 10),
+			strconv.FormatInt(d.GetCallsFailed(), 10),
+		})
+	}
+	return headers, rows
+}
+
+func socketTable(sockets []*channelzpb.GetSocketResponse) ([]string, [][]string) {
+	headers := []string{"ID", "LOCAL", "REMOTE", "STREAMS", "SUCCEEDED", "FAILED", "MESSAGES_SENT", "MESSAGES_RECEIVED"}
+	rows := make([][]string, 0, len(sockets))
+	for _, resp := range sockets {
+		s := resp.GetSocket()
+		d := s.GetData()
+		rows = append(rows, []string{
+			strconv.FormatInt(s.GetRef().GetSocketId(), 10),
+			addressString(s.GetLocal()),
+			addressString(s.GetRemote()),
+			strconv.FormatInt(d.GetStreamsStarted(), 10),
+			strconv.FormatInt(d.GetStreamsSucceeded(), 10),
+			strconv.FormatInt(d.GetStreamsFailed(), 10),
+			strconv.FormatInt(d.GetMessagesSent(), 10),
+			strconv.FormatInt(d.GetMessagesReceived(), 10),
+		})
+	}
+	return headers, rows
+}
+
+func addressString(a *channelzpb.Address) string {
+	switch {
+	case a.GetTcpipAddress() != nil:
+		tcp := a.GetTcpipAddress()
+		return net.JoinHostPort(net.IP(tcp.GetIpAddress()).String(), strconv.Itoa(int(tcp.GetPort())))
+	case a.GetUdsAddress() != nil:
+		return a.GetUdsAddress().GetFilename()
+	case a.GetOtherAddress() != nil:
+		return a.GetOtherAddress().GetName()
+	}
+	return ""
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/juliaogris/reflect/pkg/dynamictypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// dynamicServer serves all methods of the services in a protoset with
+// dynamicpb messages. A method answers with its response configured in
+// responses or otherwise, in echo mode, with the request fields that
+// have the same name and type in the response, or with an empty response.
+type dynamicServer struct {
+	files     *protoregistry.Files
+	types     *protoregistry.Types
+	services  []string
+	responses map[string]proto.Message // keyed by full method name, e.g. pkg.Service/Method
+	echo      bool
+}
+
+// newDynamicServer loads the protoset, which must contain all
+// dependencies as written by protoc --include_imports, and the JSON
+// responses file mapping full method names to response messages.
+func newDynamicServer(protoset, responsesFile string, echo bool) (*dynamicServer, error) {
+	b, err := os.ReadFile(protoset)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read protoset: %w", err)
+	}
+	fds := &dpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, fds); err != nil {
+		return nil, fmt.Errorf("cannot decode protoset: %w", err)
+	}
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load protoset: %w", err)
+	}
+	d := &dynamicServer{
+		files:     files,
+		types:     &protoregistry.Types{},
+		responses: map[string]proto.Message{},
+		echo:      echo,
+	}
+	var rangeErr error
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		for i := 0; i < fd.Services().Len(); i++ {
+			d.services = append(d.services, string(fd.Services().Get(i).FullName()))
+		}
+		rangeErr = dynamictypes.Register(d.types, fd)
+		return rangeErr == nil
+	})
+	if rangeErr != nil {
+		return nil, rangeErr
+	}
+	if responsesFile != "" {
+		if err := d.loadResponses(responsesFile); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+func (d *dynamicServer) loadResponses(filename string) error {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("cannot read responses: %w", err)
+	}
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return fmt.Errorf("cannot decode responses: %w", err)
+	}
+	for name, r := range raw {
+		md, err := d.findMethod(name)
+		if err != nil {
+			return err
+		}
+		m := dynamicpb.NewMessage(md.Output())
+		if err := (protojson.UnmarshalOptions{Resolver: d.types}).Unmarshal(r, m); err != nil {
+			return fmt.Errorf("cannot decode response of %s: %w", name, err)
+		}
+		d.responses[name] = m
+	}
+	return nil
+}
+
+// findDescriptor looks up name in the protoset and in the files linked
+// into the binary. d may be nil if there is no protoset.
+func (d *dynamicServer) findDescriptor(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d != nil {
+		if desc, err := d.files.FindDescriptorByName(name); err == nil {
+			return desc, nil
+		}
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// findMethod returns the method of a full method name such as
+// pkg.Service/Method or /pkg.Service/Method.
+func (d *dynamicServer) findMethod(fullMethod string) (protoreflect.MethodDescriptor, error) {
+	name := strings.TrimPrefix(fullMethod, "/")
+	i := strings.LastIndexByte(name, '/')
+	if i < 0 {
+		return nil, fmt.Errorf("invalid method name %s", fullMethod)
+	}
+	desc, err := d.files.FindDescriptorByName(protoreflect.FullName(name[:i]))
+	if err != nil {
+		return nil, fmt.Errorf("unknown service %s", name[:i])
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", name[:i])
+	}
+	md := sd.Methods().ByName(protoreflect.Name(name[i+1:]))
+	if md == nil {
+		return nil, fmt.Errorf("unknown method %s", name)
+	}
+	return md, nil
+}
+
+// handle is the grpc.UnknownServiceHandler serving all protoset methods.
+// Unary and server streaming methods answer the request once,
+// bidirectional streaming methods answer every request and client
+// streaming methods answer the last request.
+func (d *dynamicServer) handle(_ interface{}, stream grpc.ServerStream) error {
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	md, err := d.findMethod(fullMethod)
+	if err != nil {
+		return status.Error(codes.Unimplemented, err.Error())
+	}
+	last := dynamicpb.NewMessage(md.Input())
+	for {
+		req := dynamicpb.NewMessage(md.Input())
+		err := stream.RecvMsg(req)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		last = req
+		if md.IsStreamingClient() && !md.IsStreamingServer() {
+			continue
+		}
+		if err := stream.SendMsg(d.response(md, req)); err != nil {
+			return err
+		}
+		if !md.IsStreamingClient() {
+			return nil
+		}
+	}
+	if md.IsStreamingClient() && !md.IsStreamingServer() {
+		return stream.SendMsg(d.response(md, last))
+	}
+	return nil
+}
+
+func (d *dynamicServer) response(md protoreflect.MethodDescriptor, req protoreflect.ProtoMessage) proto.Message {
+	if r, ok := d.responses[string(md.Parent().FullName())+"/"+string(md.Name())]; ok {
+		return r
+	}
+	resp := dynamicpb.NewMessage(md.Output())
+	if d.echo {
+		echoFields(req.ProtoReflect(), resp)
+	}
+	return resp
+}
+
+// echoFields copies all fields of src to the fields of dst with the same
+// name and type.
+func echoFields(src, dst protoreflect.Message) {
+	src.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		dfd := dst.Descriptor().Fields().ByName(fd.Name())
+		if dfd == nil || !sameType(fd, dfd) {
+			return true
+		}
+		switch {
+		case fd.IsList():
+			list := dst.Mutable(dfd).List()
+			for i := 0; i < v.List().Len(); i++ {
+				list.Append(v.List().Get(i))
+			}
+		case fd.IsMap():
+			m := dst.Mutable(dfd).Map()
+			v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+				m.Set(k, v)
+				return true
+			})
+		default:
+			dst.Set(dfd, v)
+		}
+		return true
+	})
+}
+
+func sameType(a, b protoreflect.FieldDescriptor) bool {
+	if a.Kind() != b.Kind() || a.Cardinality() != b.Cardinality() || a.IsMap() != b.IsMap() {
+		return false
+	}
+	if a.IsMap() {
+		return sameType(a.MapKey(), b.MapKey()) && sameType(a.MapValue(), b.MapValue())
+	}
+	switch {
+	case a.Message() != nil:
+		return a.Message().FullName() == b.Message().FullName()
+	case a.Enum() != nil:
+		return a.Enum().FullName() == b.Enum().FullName()
+	}
+	return true
+}
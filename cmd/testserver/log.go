@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+const redacted = "REDACTED"
+
+// logEntry is a JSON log line of a gRPC call, a reflection request on a
+// ServerReflectionInfo stream or a REST request.
+type logEntry struct {
+	Time     time.Time           `json:"time"`
+	Kind     string              `json:"kind"` // unary, stream, reflection or rest
+	Method   string              `json:"method"`
+	Peer     string              `json:"peer,omitempty"`
+	Metadata map[string][]string `json:"metadata,omitempty"`
+	Request  string              `json:"request,omitempty"`  // reflection request type
+	Argument string              `json:"argument,omitempty"` // reflection request argument
+	Response string              `json:"response,omitempty"` // reflection response type
+	Code     string              `json:"code,omitempty"`
+	Status   int                 `json:"status,omitempty"` // HTTP status of REST requests
+	Received int                 `json:"received,omitempty"`
+	Sent     int                 `json:"sent,omitempty"`
+	Duration string              `json:"duration"`
+}
+
+// callLogger writes JSON log lines with the values of the redact
+// metadata keys replaced.
+type callLogger struct {
+	mu     sync.Mutex
+	w      io.Writer
+	redact map[string]bool
+	now    func() time.Time
+}
+
+func newCallLogger(w io.Writer, redact []string) *callLogger {
+	l := &callLogger{w: w, redact: map[string]bool{}, now: time.Now}
+	for _, key := range redact {
+		l.redact[strings.ToLower(key)] = true
+	}
+	return l
+}
+
+func (l *callLogger) serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(l.unary),
+		grpc.ChainStreamInterceptor(l.stream),
+	}
+}
+
+// log writes e with the duration since its start time.
+func (l *callLogger) log(e *logEntry) {
+	e.Duration = l.now().Sub(e.Time).String()
+	b, err := json.Marshal(e)
+	if err != nil {
+		b = []byte(fmt.Sprintf(`{"error": %q}`, err))
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.w.Write(append(b, '\n'))
+}
+
+// entry returns the log entry of a call on ctx with peer and redacted
+// metadata.
+func (l *callLogger) entry(ctx context.Context, kind, method string, start time.Time) *logEntry {
+	e := &logEntry{Time: start, Kind: kind, Method: method}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.Peer = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		if e.Metadata == nil {
+			e.Metadata = map[string][]string{}
+		}
+		if l.redact[key] {
+			values = []string{redacted}
+		}
+		e.Metadata[key] = values
+	}
+	return e
+}
+
+func (l *callLogger) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := l.now()
+	resp, err := handler(ctx, req)
+	e := l.entry(ctx, "unary", info.FullMethod, start)
+	e.Code = status.Code(err).String()
+	e.Received = 1
+	if err == nil {
+		e.Sent = 1
+	}
+	l.log(e)
+	return resp, err
+}
+
+func (l *callLogger) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := l.now()
+	ls := &loggedStream{ServerStream: ss, l: l, method: info.FullMethod}
+	err := handler(srv, ls)
+	e := l.entry(ss.Context(), "stream", info.FullMethod, start)
+	e.Code = status.Code(err).String()
+	e.Received = ls.received
+	e.Sent = ls.sent
+	l.log(e)
+	return err
+}
+
+// loggedStream counts the messages of a stream and logs every
+// ServerReflectionRequest with its response.
+type loggedStream struct {
+	grpc.ServerStream
+	l        *callLogger
+	method   string
+	received int
+	sent     int
+	pending  []*logEntry // reflection requests awaiting a response
+}
+
+func (s *loggedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.received++
+	if req, ok := m.(*rpb.ServerReflectionRequest); ok {
+		e := s.l.entry(s.Context(), "reflection", s.method, s.l.now())
+		e.Request, e.Argument = reflectionRequest(req)
+		s.pending = append(s.pending, e)
+	}
+	return nil
+}
+
+func (s *loggedStream) SendMsg(m interface{}) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.sent++
+	if resp, ok := m.(*rpb.ServerReflectionResponse); ok && len(s.pending) != 0 {
+		e := s.pending[0]
+		s.pending = s.pending[1:]
+		e.Response = reflectionResponse(resp)
+		e.Code = codes.OK.String()
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			e.Code = codes.Code(errResp.GetErrorCode()).String()
+		}
+		s.l.log(e)
+	}
+	return nil
+}
+
+// reflectionRequest returns the type and argument of a reflection
+// request, e.g. file_containing_symbol and echo3.Echo.
+func reflectionRequest(req *rpb.ServerReflectionRequest) (string, string) {
+	switch mr := req.MessageRequest.(type) {
+	case *rpb.ServerReflectionRequest_FileByFilename:
+		return "file_by_filename", mr.FileByFilename
+	case *rpb.ServerReflectionRequest_FileContainingSymbol:
+		return "file_containing_symbol", mr.FileContainingSymbol
+	case *rpb.ServerReflectionRequest_FileContainingExtension:
+		ext := mr.FileContainingExtension
+		return "file_containing_extension", fmt.Sprintf("%s %d", ext.GetContainingType(), ext.GetExtensionNumber())
+	case *rpb.ServerReflectionRequest_AllExtensionNumbersOfType:
+		return "all_extension_numbers_of_type", mr.AllExtensionNumbersOfType
+	case *rpb.ServerReflectionRequest_ListServices:
+		return "list_services", mr.ListServices
+	}
+	return "unknown", ""
+}
+
+func reflectionResponse(resp *rpb.ServerReflectionResponse) string {
+	switch resp.MessageResponse.(type) {
+	case *rpb.ServerReflectionResponse_FileDescriptorResponse:
+		return "file_descriptor_response"
+	case *rpb.ServerReflectionResponse_AllExtensionNumbersResponse:
+		return "all_extension_numbers_response"
+	case *rpb.ServerReflectionResponse_ListServicesResponse:
+		return "list_services_response"
+	case *rpb.ServerReflectionResponse_ErrorResponse:
+		return "error_response"
+	}
+	return "unknown"
+}
+
+// logREST logs every request to h with its response status.
+func (l *callLogger) logREST(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e := &logEntry{Time: l.now(), Kind: "rest", Method: r.Method + " " + r.URL.Path, Peer: r.RemoteAddr}
+		sw := &statusWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, r)
+		e.Status = sw.status
+		if e.Status == 0 {
+			e.Status = http.StatusOK
+		}
+		l.log(e)
+	})
+}
+
+// statusWriter records the status written to a ResponseWriter and
+// forwards flushes of streamed responses.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
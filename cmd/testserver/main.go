@@ -1,22 +1,51 @@
 package main
 
 import (
+	"crypto/tls"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
-	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/juliaogris/reflect/pkg/echo2"
 	"github.com/juliaogris/reflect/pkg/echo3"
-	"golang.org/x/net/http2"
-	"golang.org/x/net/http2/h2c"
+	"github.com/juliaogris/reflect/pkg/faults"
+	"github.com/juliaogris/reflect/pkg/library"
+	"github.com/juliaogris/reflect/pkg/reflectserver"
+	"github.com/soheilhy/cmux"
 	"google.golang.org/grpc"
+	channelz "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
 type config struct {
-	Address string `short:"a" help:"gRPC server address, host:port" placeholder:"ADDRESS" env:"GURL_ADDRESS" default:"localhost:9090"`
+	Address      string `short:"a" help:"gRPC server address, host:port" placeholder:"ADDRESS" env:"GURL_ADDRESS" default:"localhost:9090"`
+	TLSCert      string `help:"serve TLS with this certificate file, PEM" type:"existingfile"`
+	TLSKey       string `help:"private key file of --tls-cert, PEM" type:"existingfile"`
+	ClientCA     string `help:"require client certificates signed by this CA file, PEM" type:"existingfile"`
+	SelfSigned   bool   `help:"Serve TLS with a certificate generated at startup"`
+	SelfSignedCA string `help:"file to write the CA certificate of --self-signed to" default:"testserver-ca.pem" type:"path"`
+
+	Protoset        string `help:"serve all services of this FileDescriptorSet, including imports, with generic handlers" type:"existingfile"`
+	Responses       string `help:"JSON file mapping full method names, e.g. pkg.Service/Method, to responses of protoset methods" type:"existingfile"`
+	DynamicResponse string `help:"response of protoset methods not in --responses: echo copies request fields of same name and type, empty" enum:"echo,empty" default:"echo"`
+
+	FaultMethods   string        `help:"regular expression of full method names faults are injected into, default: all" group:"Faults"`
+	Latency        time.Duration `help:"delay calls and streamed messages by this duration" group:"Faults"`
+	FailCode       string        `help:"fail calls with this gRPC status code, by name or number, e.g. unavailable or 14" group:"Faults"`
+	DropAfter      int           `help:"abort reflection streams with ABORTED after this many responses" group:"Faults"`
+	MalformedFiles bool          `help:"replace file_descriptor_proto bytes in reflection responses with invalid bytes" group:"Faults"`
+	OutOfOrder     bool          `help:"answer every reflection request but the first with the response to the previous request" group:"Faults"`
+
+	Log    string   `help:"file to write JSON lines logging every call and reflection request to, default: stdout" default:"-" type:"path"`
+	Redact []string `help:"metadata keys whose values are redacted in logs" default:"authorization,cookie,x-api-key"`
+
+	logger *callLogger
 }
 
 var cfg = &config{}
@@ -24,45 +53,144 @@ var cfg = &config{}
 func main() {
 	_ = kong.Parse(cfg)
 
-	fmt.Println("Starting testserver on", cfg.Address)
-	if err := run(cfg.Address); err != nil {
+	if err := run(cfg); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
-func run(addr string) error {
-	s := grpc.NewServer()
+func run(c *config) error {
+	logOut := io.Writer(os.Stdout)
+	if c.Log != "-" {
+		f, err := os.Create(c.Log)
+		if err != nil {
+			return fmt.Errorf("cannot create log file: %w", err)
+		}
+		defer f.Close()
+		logOut = f
+	}
+	c.logger = newCallLogger(logOut, c.Redact)
+	lis, err := listen(c)
+	if err != nil {
+		return err
+	}
+	return serve(c, lis)
+}
+
+// listen listens on the address of c, with TLS if configured.
+func listen(c *config) (net.Listener, error) {
+	tlsCfg, err := tlsConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	lis, err := net.Listen("tcp", c.Address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot listen on %s: %w", c.Address, err)
+	}
+	// Status messages go to stderr, keeping stdout for the --log lines.
+	if tlsCfg == nil {
+		fmt.Fprintln(os.Stderr, "Starting testserver on", c.Address)
+		return lis, nil
+	}
+	fmt.Fprintln(os.Stderr, "Starting testserver with TLS on", c.Address)
+	if c.SelfSigned {
+		fmt.Fprintln(os.Stderr, "Wrote CA certificate to", c.SelfSignedCA)
+	}
+	return tls.NewListener(lis, tlsCfg), nil
+}
+
+// serve serves gRPC and REST on lis until serving fails, e.g. because lis
+// is closed. HTTP/2 connections with a gRPC content-type are served by the
+// gRPC server's own transport, so that channelz sees their sockets, and
+// HTTP/1 connections by the REST transcoder.
+func serve(c *config, lis net.Listener) error {
+	s, dynamic, err := newServer(c)
+	if err != nil {
+		return err
+	}
+	rest, err := newTranscoder(s, dynamic)
+	if err != nil {
+		s.Stop()
+		return err
+	}
+	defer rest.Close()
+	var restHandler http.Handler = rest
+	if c.logger != nil {
+		restHandler = c.logger.logREST(rest)
+	}
+	h := &http.Server{Handler: restHandler}
+
+	// gRPC clients wait for the server's HTTP/2 settings before sending
+	// their first request. REST requests may use any method, including
+	// PATCH and the custom kinds of HTTP rules.
+	m := cmux.New(lis)
+	grpcLis := m.MatchWithWriters(cmux.HTTP2MatchHeaderFieldPrefixSendSettings("content-type", "application/grpc"))
+	httpLis := m.Match(cmux.HTTP1())
+	errc := make(chan error, 3)
+	go func() { errc <- s.Serve(grpcLis) }()
+	go func() { errc <- h.Serve(httpLis) }()
+	go func() { errc <- m.Serve() }()
+	err = <-errc
+	s.Stop()
+	_ = h.Close()
+	m.Close()
+	return fmt.Errorf("failed to serve: %w", err)
+}
+
+// newServer returns the gRPC server with all services registered and, if
+// there is a protoset, the dynamic server handling its services.
+func newServer(c *config) (*grpc.Server, *dynamicServer, error) {
+	injector, err := newInjector(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	var opts []grpc.ServerOption
+	if c.logger != nil {
+		opts = c.logger.serverOptions()
+	}
+	opts = append(opts, injector.ServerOptions()...)
+	var dynamic *dynamicServer
+	if c.Protoset != "" {
+		dynamic, err = newDynamicServer(c.Protoset, c.Responses, c.DynamicResponse == "echo")
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, grpc.UnknownServiceHandler(dynamic.handle))
+	}
+	s := grpc.NewServer(opts...)
 	echo2Server := &echo2.Server{}
 	echo2.RegisterEchoServer(s, echo2Server)
 	echo3Server := &echo3.Server{}
 	echo3.RegisterEchoServer(s, echo3Server)
-	reflection.Register(s)
-
-	h := &http.Server{
-		Addr:    addr,
-		Handler: rootHandler(s),
-	}
-	if err := h.ListenAndServe(); err != nil {
-		return fmt.Errorf("failed to serve gRPC service: %w", err)
+	library.RegisterLibraryServer(s, &library.Server{})
+	if dynamic != nil {
+		reflectserver.Register(s, dynamic.files, dynamic.types, dynamic.services)
+	} else {
+		reflection.Register(s)
 	}
-	return nil
+	channelz.RegisterChannelzServiceToServer(s)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("echo2.Echo", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("echo3.Echo", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("library.Library", healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(s, healthServer)
+	return s, dynamic, nil
 }
 
-// From: https://github.com/philips/grpc-gateway-example/issues/22#issuecomment-490733965
-// Use x/net/http2/h2c so we can have http2 cleartext connections.
-func rootHandler(grpcServer http.Handler) http.Handler {
-	hf := func(w http.ResponseWriter, r *http.Request) {
-		var label string
-		switch {
-		case r.ProtoMajor == 2 && strings.Contains(r.Header.Get("Content-Type"), "application/grpc"):
-			label = "grpc"
-			grpcServer.ServeHTTP(w, r)
-		default:
-			label = "error"
-			http.Error(w, r.URL.Path+": not Implemented", http.StatusNotImplemented)
+func newInjector(c *config) (*faults.Injector, error) {
+	fc := faults.Config{
+		Methods:        c.FaultMethods,
+		Latency:        c.Latency,
+		DropAfter:      c.DropAfter,
+		MalformedFiles: c.MalformedFiles,
+		OutOfOrder:     c.OutOfOrder,
+	}
+	if c.FailCode != "" {
+		code, err := faults.ParseCode(c.FailCode)
+		if err != nil {
+			return nil, err
 		}
-		fmt.Printf("%-5s: %-4s %s %s\n", label, r.Method, r.URL.Path, r.RemoteAddr)
+		fc.Code = code
 	}
-	return h2c.NewHandler(http.HandlerFunc(hf), &http2.Server{})
+	return faults.New(fc)
 }
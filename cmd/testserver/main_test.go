@@ -0,0 +1,701 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juliaogris/reflect/pkg/echo2"
+	"github.com/juliaogris/reflect/pkg/echo3"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	channelzpb "google.golang.org/grpc/channelz/grpc_channelz_v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const mirrorProto = `
+name: "mirror/mirror.proto"
+package: "mirror"
+syntax: "proto3"
+message_type: {
+  name: "Msg"
+  field: {name: "text" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "text"}
+  field: {name: "nums" number: 2 type: TYPE_INT32 label: LABEL_REPEATED json_name: "nums"}
+}
+message_type: {
+  name: "Other"
+  field: {name: "text" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "text"}
+  field: {name: "nums" number: 2 type: TYPE_INT64 label: LABEL_OPTIONAL json_name: "nums"}
+}
+service: {
+  name: "Mirror"
+  method: {name: "Reflect" input_type: ".mirror.Msg" output_type: ".mirror.Msg"}
+  method: {name: "Partial" input_type: ".mirror.Msg" output_type: ".mirror.Other"}
+  method: {name: "Collect" input_type: ".mirror.Msg" output_type: ".mirror.Msg" client_streaming: true}
+}
+`
+
+// startServer serves the testserver configured by c until the end of the
+// test and returns its address, by default on a free local port.
+func startServer(t *testing.T, c *config) string {
+	t.Helper()
+	if c.Address == "" {
+		c.Address = "localhost:0"
+	}
+	lis, err := listen(c)
+	require.NoError(t, err)
+	done := make(chan error)
+	go func() { done <- serve(c, lis) }()
+	t.Cleanup(func() {
+		_ = lis.Close()
+		<-done
+	})
+	return lis.Addr().String()
+}
+
+// writeProtoset writes the file given as FileDescriptorProto text to a
+// protoset and returns the file and the protoset path.
+func writeProtoset(t *testing.T, text string) (protoreflect.FileDescriptor, string) {
+	t.Helper()
+	fdp := &dpb.FileDescriptorProto{}
+	require.NoError(t, prototext.Unmarshal([]byte(text), fdp))
+	fd, err := protodesc.NewFile(fdp, nil)
+	require.NoError(t, err)
+	b, err := proto.Marshal(&dpb.FileDescriptorSet{File: []*dpb.FileDescriptorProto{fdp}})
+	require.NoError(t, err)
+	protoset := path.Join(t.TempDir(), "protoset.pb")
+	require.NoError(t, os.WriteFile(protoset, b, 0600))
+	return fd, protoset
+}
+
+// startDynamicServer starts a testserver serving the mirror protoset with
+// the given responses and returns the mirror file and a connection to it.
+func startDynamicServer(t *testing.T, responses string) (protoreflect.FileDescriptor, *grpc.ClientConn) {
+	t.Helper()
+	fd, protoset := writeProtoset(t, mirrorProto)
+	c := &config{Protoset: protoset, DynamicResponse: "echo"}
+	if responses != "" {
+		c.Responses = path.Join(t.TempDir(), "responses.json")
+		require.NoError(t, os.WriteFile(c.Responses, []byte(responses), 0600))
+	}
+
+	conn, err := grpc.Dial(startServer(t, c), grpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return fd, conn
+}
+
+func TestDynamicServer(t *testing.T) {
+	fd, conn := startDynamicServer(t, `{"mirror.Mirror/Partial": {"text": "configured"}}`)
+	msg, other := fd.Messages().ByName("Msg"), fd.Messages().ByName("Other")
+	req := dynamicpb.NewMessage(msg)
+	require.NoError(t, protojson.Unmarshal([]byte(`{"text": "hi", "nums": [1, 2]}`), req))
+
+	resp := dynamicpb.NewMessage(msg)
+	err := conn.Invoke(context.Background(), "/mirror.Mirror/Reflect", req, resp)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(req, resp))
+
+	resp = dynamicpb.NewMessage(other)
+	err = conn.Invoke(context.Background(), "/mirror.Mirror/Partial", req, resp)
+	require.NoError(t, err)
+	requireJSON(t, `{"text": "configured"}`, resp)
+
+	stream, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ClientStreams: true}, "/mirror.Mirror/Collect")
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(req))
+	last := dynamicpb.NewMessage(msg)
+	require.NoError(t, protojson.Unmarshal([]byte(`{"text": "bye"}`), last))
+	require.NoError(t, stream.SendMsg(last))
+	require.NoError(t, stream.CloseSend())
+	resp = dynamicpb.NewMessage(msg)
+	require.NoError(t, stream.RecvMsg(resp))
+	requireJSON(t, `{"text": "bye"}`, resp)
+
+	err = conn.Invoke(context.Background(), "/mirror.Mirror/Missing", req, resp)
+	require.Error(t, err)
+}
+
+func TestDynamicEcho(t *testing.T) {
+	fd, conn := startDynamicServer(t, "")
+	req := dynamicpb.NewMessage(fd.Messages().ByName("Msg"))
+	require.NoError(t, protojson.Unmarshal([]byte(`{"text": "hi", "nums": [1, 2]}`), req))
+	resp := dynamicpb.NewMessage(fd.Messages().ByName("Other"))
+	err := conn.Invoke(context.Background(), "/mirror.Mirror/Partial", req, resp)
+	require.NoError(t, err)
+	requireJSON(t, `{"text": "hi"}`, resp)
+}
+
+func TestReflectionServer(t *testing.T) {
+	_, conn := startDynamicServer(t, "")
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	defer func() { _ = stream.CloseSend() }()
+	send := func(req *rpb.ServerReflectionRequest) *rpb.ServerReflectionResponse {
+		require.NoError(t, stream.Send(req))
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_ListServices{}})
+	var names []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		names = append(names, service.GetName())
+	}
+	require.Contains(t, names, "mirror.Mirror")
+	require.Contains(t, names, "echo3.Echo")
+	require.Contains(t, names, "grpc.reflection.v1alpha.ServerReflection")
+
+	require.Contains(t, names, "library.Library")
+
+	for _, symbol := range []string{"mirror.Other", "echo3.Echo", "library.Library"} {
+		resp = send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol}})
+		files := resp.GetFileDescriptorResponse().GetFileDescriptorProto()
+		require.NotEmpty(t, files, symbol)
+	}
+
+	resp = send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: "mirror/mirror.proto"}})
+	files := resp.GetFileDescriptorResponse().GetFileDescriptorProto()
+	require.Len(t, files, 1)
+	fdp := &dpb.FileDescriptorProto{}
+	require.NoError(t, proto.Unmarshal(files[0], fdp))
+	require.Equal(t, "mirror/mirror.proto", fdp.GetName())
+
+	// Book is defined in types.proto, publicly imported by public.proto.
+	// All dependencies have been sent with library.Library above.
+	resp = send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_FileContainingExtension{FileContainingExtension: &rpb.ExtensionRequest{ContainingType: "library.types.Book", ExtensionNumber: 100}}})
+	files = resp.GetFileDescriptorResponse().GetFileDescriptorProto()
+	require.Len(t, files, 1)
+	require.NoError(t, proto.Unmarshal(files[0], fdp))
+	require.Equal(t, "library/library.proto", fdp.GetName())
+
+	resp = send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: "mirror.Missing"}})
+	require.NotNil(t, resp.GetErrorResponse())
+
+	resp = send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_AllExtensionNumbersOfType{AllExtensionNumbersOfType: "google.protobuf.MethodOptions"}})
+	require.Contains(t, resp.GetAllExtensionNumbersResponse().GetExtensionNumber(), int32(72295728))
+}
+
+func TestEchoStreaming(t *testing.T) {
+	conn, err := grpc.Dial(startServer(t, &config{}), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+
+	collect, err := echo3.NewEchoClient(conn).HelloCollect(ctx)
+	require.NoError(t, err)
+	for _, msg := range []string{"a", "b", "c"} {
+		require.NoError(t, collect.Send(&echo3.HelloRequest{Message: msg}))
+	}
+	resp, err := collect.CloseAndRecv()
+	require.NoError(t, err)
+	require.Equal(t, "And to you: a, b, c", resp.RobotResponse)
+
+	chat, err := echo2.NewEchoClient(conn).HelloChat(ctx)
+	require.NoError(t, err)
+	for _, msg := range []string{"a", "b"} {
+		msg := msg
+		require.NoError(t, chat.Send(&echo2.HelloRequest{Message: &msg}))
+		resp, err := chat.Recv()
+		require.NoError(t, err)
+		require.Equal(t, "And to you: "+msg, resp.GetRobotResponse())
+	}
+	require.NoError(t, chat.CloseSend())
+	_, err = chat.Recv()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestEchoDetails(t *testing.T) {
+	addr := startServer(t, &config{})
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	anyValue, err := anypb.New(&echo3.PublicNotification{Content: "hi"})
+	require.NoError(t, err)
+	details := &echo3.Details{
+		LabelCount: map[string]int64{"a": 1, "b": -2},
+		ColorType:  echo3.ColorType_GREEN,
+		Any:        anyValue,
+		Notifications: []*echo3.Notification{
+			{Id: 1, Instrument: &echo3.Notification_Private{Private: &echo3.PrivateNotification{SecretContent: "s"}}},
+			{Id: 2, Instrument: &echo3.Notification_Public{Public: &echo3.PublicNotification{Content: "p"}}},
+		},
+		AInt32: -1, AUint32: 2, AInt64: -3, AUint64: 4, ABool: true, ASint32: -5, ASint64: -6,
+		AString: "x", ABytes: []byte{0, 1}, AFixed32: 7, ASfixed32: -8, AFixed64: 9, ASfixed64: -10,
+	}
+	resp, err := echo3.NewEchoClient(conn).HelloDetails(context.Background(), details)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(details, resp))
+
+	b, err := protojson.Marshal(details)
+	require.NoError(t, err)
+	details2 := &echo2.Details{}
+	require.NoError(t, protojson.Unmarshal(b, details2))
+	resp2, err := echo2.NewEchoClient(conn).HelloDetails(context.Background(), details2)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(details2, resp2))
+
+	httpResp, err := http.Post("http://"+addr+"/api/echo/details", "application/json", bytes.NewReader(b))
+	require.NoError(t, err)
+	got, err := io.ReadAll(httpResp.Body)
+	require.NoError(t, err)
+	require.NoError(t, httpResp.Body.Close())
+	require.Equal(t, http.StatusOK, httpResp.StatusCode)
+	require.JSONEq(t, string(b), string(got))
+}
+
+func TestEchoError(t *testing.T) {
+	addr := startServer(t, &config{})
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := echo3.NewEchoClient(conn)
+
+	resp, err := client.HelloError(context.Background(), &echo3.ErrorRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "No error", resp.RobotResponse)
+
+	_, err = client.HelloError(context.Background(), &echo3.ErrorRequest{Code: int32(codes.InvalidArgument), Message: "bad"})
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+	require.Equal(t, "bad", st.Message())
+	details := st.Details()
+	require.Len(t, details, 3)
+	require.IsType(t, &errdetails.BadRequest{}, details[0])
+	require.Equal(t, "echo3.Echo", details[1].(*errdetails.ErrorInfo).Domain)
+	require.Equal(t, time.Second, details[2].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+
+	httpResp, err := http.Post("http://"+addr+"/api/echo/error", "application/json", strings.NewReader(`{"code": 5, "message": "gone"}`))
+	require.NoError(t, err)
+	b, err := io.ReadAll(httpResp.Body)
+	require.NoError(t, err)
+	require.NoError(t, httpResp.Body.Close())
+	require.Equal(t, http.StatusNotFound, httpResp.StatusCode)
+	require.Contains(t, string(b), "type.googleapis.com/google.rpc.RetryInfo")
+}
+
+func TestServeChannelz(t *testing.T) {
+	addr := startServer(t, &config{})
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := channelzpb.NewChannelzClient(conn)
+	ctx := context.Background()
+
+	servers, err := client.GetServers(ctx, &channelzpb.GetServersRequest{})
+	require.NoError(t, err)
+	var listening *channelzpb.Server
+	for _, s := range servers.GetServer() {
+		if len(s.GetListenSocket()) != 0 {
+			listening = s
+		}
+	}
+	require.NotNil(t, listening)
+
+	// Besides the in-process connection of the REST transcoder, the
+	// server sees the socket of this client.
+	sockets, err := client.GetServerSockets(ctx, &channelzpb.GetServerSocketsRequest{ServerId: listening.GetRef().GetServerId()})
+	require.NoError(t, err)
+	var locals []string
+	for _, ref := range sockets.GetSocketRef() {
+		socket, err := client.GetSocket(ctx, &channelzpb.GetSocketRequest{SocketId: ref.GetSocketId()})
+		require.NoError(t, err)
+		if local := socket.GetSocket().GetLocal().GetTcpipAddress(); local != nil {
+			locals = append(locals, net.JoinHostPort(net.IP(local.GetIpAddress()).String(), strconv.Itoa(int(local.GetPort()))))
+		}
+	}
+	require.Equal(t, []string{addr}, locals)
+
+	// REST requests are served on the same port.
+	resp, err := http.Get("http://" + addr + "/api/echo/hello")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+func TestTLSConfig(t *testing.T) {
+	tests := map[string]*config{
+		"cert without key":       {TLSCert: "cert.pem"},
+		"key without cert":       {TLSKey: "key.pem"},
+		"self-signed and cert":   {SelfSigned: true, TLSCert: "cert.pem", TLSKey: "key.pem"},
+		"client CA without TLS":  {ClientCA: "ca.pem"},
+		"missing cert":           {TLSCert: "MISSING", TLSKey: "MISSING"},
+		"missing client CA":      {SelfSigned: true, SelfSignedCA: path.Join(t.TempDir(), "ca.pem"), ClientCA: "MISSING"},
+		"client CA without cert": {SelfSigned: true, SelfSignedCA: path.Join(t.TempDir(), "ca.pem"), ClientCA: "main.go"},
+	}
+	for name, c := range tests {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			_, err := tlsConfig(c)
+			require.Error(t, err)
+		})
+	}
+	cfg, err := tlsConfig(&config{})
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+}
+
+// newClientCert writes a new CA certificate to caFile and returns a client
+// certificate signed by it.
+func newClientCert(t *testing.T, caFile string) tls.Certificate {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600))
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// checkHealth calls the health service of the TLS server at addr with
+// the CA certificate in caFile and the given client certificates.
+func checkHealth(t *testing.T, addr, caFile string, certs ...tls.Certificate) error {
+	t.Helper()
+	b, err := os.ReadFile(caFile)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(b))
+	creds := credentials.NewTLS(&tls.Config{RootCAs: roots, Certificates: certs, MinVersion: tls.VersionTLS12})
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(creds))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func TestServeTLS(t *testing.T) {
+	dir := t.TempDir()
+	c := &config{SelfSigned: true, SelfSignedCA: path.Join(dir, "ca.pem")}
+	addr := startServer(t, c)
+	require.NoError(t, checkHealth(t, addr, c.SelfSignedCA))
+
+	// REST is served on the same port.
+	b, err := os.ReadFile(c.SelfSignedCA)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(b))
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}}}
+	resp, err := client.Post("https://"+addr+"/api/echo/hello", "application/json", strings.NewReader(`{"message": "hi"}`))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The certificate of --tls-cert and --tls-key is served.
+	cert, err := selfSigned("localhost:0", path.Join(dir, "ca2.pem"))
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+	c = &config{TLSCert: path.Join(dir, "cert.pem"), TLSKey: path.Join(dir, "key.pem")}
+	require.NoError(t, os.WriteFile(c.TLSCert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0600))
+	require.NoError(t, os.WriteFile(c.TLSKey, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+	addr = startServer(t, c)
+	require.NoError(t, checkHealth(t, addr, path.Join(dir, "ca2.pem")))
+	require.Error(t, checkHealth(t, addr, path.Join(dir, "ca.pem")))
+}
+
+func TestServeMTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCAFile := path.Join(dir, "client-ca.pem")
+	clientCert := newClientCert(t, clientCAFile)
+	c := &config{SelfSigned: true, SelfSignedCA: path.Join(dir, "ca.pem"), ClientCA: clientCAFile}
+	addr := startServer(t, c)
+
+	require.NoError(t, checkHealth(t, addr, c.SelfSignedCA, clientCert))
+	require.Error(t, checkHealth(t, addr, c.SelfSignedCA))
+	otherCert := newClientCert(t, path.Join(dir, "other-ca.pem"))
+	require.Error(t, checkHealth(t, addr, c.SelfSignedCA, otherCert))
+}
+
+func TestNewInjector(t *testing.T) {
+	for _, code := range []string{"unavailable", "UNAVAILABLE", "14"} {
+		_, err := newInjector(&config{FailCode: code})
+		require.NoError(t, err, code)
+	}
+	_, err := newInjector(&config{FailCode: "bogus"})
+	require.Error(t, err)
+	_, err = newInjector(&config{FaultMethods: "("})
+	require.Error(t, err)
+}
+
+func TestCallLogger(t *testing.T) {
+	b := &bytes.Buffer{}
+	logger := newCallLogger(b, []string{"Authorization"})
+	logger.now = func() time.Time { return time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC) }
+	// The server is stopped, with all calls logged, at the end of the
+	// subtest.
+	t.Run("calls", func(t *testing.T) {
+		conn, err := grpc.Dial(startServer(t, &config{logger: logger}), grpc.WithInsecure())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "secret", "x-trace", "t1")
+		_, err = echo3.NewEchoClient(conn).Hello(ctx, &echo3.HelloRequest{Message: "hi"})
+		require.NoError(t, err)
+
+		stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+		require.NoError(t, err)
+		for _, req := range []*rpb.ServerReflectionRequest{
+			{MessageRequest: &rpb.ServerReflectionRequest_ListServices{}},
+			{MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: "missing.Symbol"}},
+		} {
+			require.NoError(t, stream.Send(req))
+			_, err := stream.Recv()
+			require.NoError(t, err)
+		}
+		require.NoError(t, stream.CloseSend())
+		_, err = stream.Recv()
+		require.Equal(t, io.EOF, err)
+	})
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		e := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal([]byte(line), &e), line)
+		require.Equal(t, "2021-05-01T00:00:00Z", e["time"])
+		require.Equal(t, "0s", e["duration"])
+		require.NotEmpty(t, e["peer"])
+		require.NotEmpty(t, e["metadata"])
+		delete(e, "time")
+		delete(e, "duration")
+		delete(e, "peer")
+		entries = append(entries, e)
+	}
+	md := entries[0]["metadata"].(map[string]interface{})
+	require.Equal(t, []interface{}{"REDACTED"}, md["authorization"])
+	require.Equal(t, []interface{}{"t1"}, md["x-trace"])
+	for _, e := range entries {
+		delete(e, "metadata")
+	}
+	reflectionMethod := "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo"
+	want := []map[string]interface{}{
+		{"kind": "unary", "method": "/echo3.Echo/Hello", "code": "OK", "received": 1.0, "sent": 1.0},
+		{"kind": "reflection", "method": reflectionMethod, "request": "list_services", "response": "list_services_response", "code": "OK"},
+		{"kind": "reflection", "method": reflectionMethod, "request": "file_containing_symbol", "argument": "missing.Symbol", "response": "error_response", "code": "NotFound"},
+		{"kind": "stream", "method": reflectionMethod, "code": "OK", "received": 2.0, "sent": 2.0},
+	}
+	require.Equal(t, want, entries)
+}
+
+func TestLogREST(t *testing.T) {
+	b := &bytes.Buffer{}
+	logger := newCallLogger(b, nil)
+	logger.now = func() time.Time { return time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC) }
+	h := logger.logREST(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("a"))
+		w.(http.Flusher).Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	require.True(t, rec.Flushed)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/missing", nil))
+
+	want := `{"time":"2021-05-01T00:00:00Z","kind":"rest","method":"GET /stream","peer":"192.0.2.1:1234","status":200,"duration":"0s"}
+{"time":"2021-05-01T00:00:00Z","kind":"rest","method":"POST /missing","peer":"192.0.2.1:1234","status":404,"duration":"0s"}
+`
+	require.Equal(t, want, b.String())
+}
+
+func requireJSON(t *testing.T, want string, m proto.Message) {
+	t.Helper()
+	b, err := protojson.Marshal(m)
+	require.NoError(t, err)
+	require.JSONEq(t, want, string(b))
+}
+
+func TestTranscoder(t *testing.T) {
+	url := "http://" + startServer(t, &config{})
+
+	resp, err := http.Post(url+"/api/echo/hello", "application/json", strings.NewReader(`{"message": "hi"}`))
+	require.NoError(t, err)
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.JSONEq(t, `{"robotResponse": "And to you: hi"}`, string(b))
+
+	resp, err = http.Post(url+"/api/echo/stream", "application/json", strings.NewReader(`{"message": "hi"}`))
+	require.NoError(t, err)
+	b, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	want := `{"robotResponse":"0. hi"}
+{"robotResponse":"1. hi"}
+{"robotResponse":"2. hi"}
+`
+	require.Equal(t, want, string(b))
+
+	resp, err = http.Post(url+"/api/echo/hello", "application/json", strings.NewReader(`{"unknown": 1}`))
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(url + "/api/echo/hello")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+}
+
+const routesProto = `
+name: "routes.proto"
+package: "routes"
+syntax: "proto3"
+message_type: {
+  name: "Msg"
+  field: {name: "text" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL json_name: "text"}
+}
+service: {
+  name: "Routes"
+  method: {
+    name: "Patch" input_type: ".routes.Msg" output_type: ".routes.Msg"
+    options: {[google.api.http]: {patch: "/routes/{text}"}}
+  }
+  method: {
+    name: "Purge" input_type: ".routes.Msg" output_type: ".routes.Msg"
+    options: {[google.api.http]: {custom: {kind: "PURGE" path: "/routes/{text}"}}}
+  }
+}
+`
+
+func TestTranscoderMethods(t *testing.T) {
+	_, protoset := writeProtoset(t, routesProto)
+	url := "http://" + startServer(t, &config{Protoset: protoset, DynamicResponse: "echo"})
+
+	tests := map[string]struct {
+		method string
+		path   string
+		status int
+		want   string
+	}{
+		"patch":           {method: http.MethodPatch, path: "/routes/a", status: http.StatusOK, want: `{"text": "a"}`},
+		"custom":          {method: "PURGE", path: "/routes/b", status: http.StatusOK, want: `{"text": "b"}`},
+		"patch no route":  {method: http.MethodPatch, path: "/nope", status: http.StatusNotImplemented},
+		"custom no route": {method: "PURGE", path: "/nope", status: http.StatusNotImplemented},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, url+tc.path, nil)
+			require.NoError(t, err)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			b, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+			require.Equal(t, tc.status, resp.StatusCode)
+			if tc.want != "" {
+				require.JSONEq(t, tc.want, string(b))
+			}
+		})
+	}
+}
+
+func TestTranscoderClose(t *testing.T) {
+	s, dynamic, err := newServer(&config{})
+	require.NoError(t, err)
+	defer s.Stop()
+	rest, err := newTranscoder(s, dynamic)
+	require.NoError(t, err)
+	require.NoError(t, rest.Close())
+	require.Equal(t, connectivity.Shutdown, rest.conn.GetState())
+}
+
+func TestPathTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		path string
+		want map[string]string
+	}{
+		{"/api/echo/hello", "/api/echo/hello", map[string]string{}},
+		{"/api/echo/hello", "/api/echo/bye", nil},
+		{"/v1/{name}", "/v1/x", map[string]string{"name": "x"}},
+		{"/v1/{name}", "/v1/x/y", nil},
+		{"/v1/{name=shelves/*}/books/{book.id}", "/v1/shelves/1/books/2", map[string]string{"name": "shelves/1", "book.id": "2"}},
+		{"/v1/{name=shelves/*}/books/{book.id}", "/v1/racks/1/books/2", nil},
+		{"/v1/{path=**}", "/v1/a/b/c", map[string]string{"path": "a/b/c"}},
+		{"/v1/{name}:cancel", "/v1/x:cancel", map[string]string{"name": "x"}},
+		{"/v1/{name}:cancel", "/v1/x", nil},
+	}
+	for _, tc := range tests {
+		p, err := parsePathTemplate(tc.tmpl)
+		require.NoError(t, err, tc.tmpl)
+		got, ok := p.match(tc.path)
+		require.Equal(t, tc.want != nil, ok, tc.tmpl+" "+tc.path)
+		if ok {
+			require.Equal(t, tc.want, got, tc.tmpl+" "+tc.path)
+		}
+	}
+	for _, tmpl := range []string{"v1/x", "/v1/{name", "/v1/**/x"} {
+		_, err := parsePathTemplate(tmpl)
+		require.Error(t, err, tmpl)
+	}
+}
+
+func TestSetField(t *testing.T) {
+	m := dynamicpb.NewMessage(echo3.File_echo3_echo3_proto.Messages().ByName("HelloRequest"))
+	require.NoError(t, setField(m, "message", "hi"))
+	require.NoError(t, setField(m, "more_details.a_int32", "5"))
+	require.NoError(t, setField(m, "moreDetails.colorType", "BLUE"))
+	require.NoError(t, setField(m, "more_details.a_bytes", "aGk="))
+	requireJSON(t, `{"message": "hi", "moreDetails": {"aInt32": 5, "colorType": "BLUE", "aBytes": "aGk="}}`, m)
+
+	require.Error(t, setField(m, "more_details.a_int32", "x"))
+	require.Error(t, setField(m, "more_details.notifications", "x"))
+	require.Error(t, setField(m, "missing", "x"))
+}
@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// transcoder serves HTTP/JSON requests for the methods annotated with
+// google.api.http rules by calling them on the gRPC server in-process.
+// Server streaming responses are written as newline separated JSON
+// messages, flushed one by one.
+type transcoder struct {
+	conn   *grpc.ClientConn
+	routes []*route
+}
+
+type route struct {
+	httpMethod string
+	tmpl       *pathTemplate
+	rule       *annotations.HttpRule
+	md         protoreflect.MethodDescriptor
+}
+
+// newTranscoder collects the HTTP rules of all services of s and of the
+// protoset of d, which may be nil. Routes are matched in the order of the
+// sorted service names and methods; clashing routes are skipped.
+func newTranscoder(s *grpc.Server, d *dynamicServer) (*transcoder, error) {
+	names := map[string]bool{}
+	for name := range s.GetServiceInfo() {
+		names[name] = true
+	}
+	if d != nil {
+		for _, name := range d.services {
+			names[name] = true
+		}
+	}
+	services := make([]string, 0, len(names))
+	for name := range names {
+		services = append(services, name)
+	}
+	sort.Strings(services)
+
+	t := &transcoder{}
+	seen := map[string]string{}
+	for _, name := range services {
+		desc, err := d.findDescriptor(protoreflect.FullName(name))
+		if err != nil {
+			continue
+		}
+		sd, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		for i := 0; i < sd.Methods().Len(); i++ {
+			md := sd.Methods().Get(i)
+			rule, ok := proto.GetExtension(md.Options(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil || md.IsStreamingClient() {
+				continue
+			}
+			for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				rt, err := newRoute(r, md)
+				if err != nil {
+					return nil, err
+				}
+				key := rt.httpMethod + " " + rt.tmpl.String()
+				if prev, ok := seen[key]; ok {
+					fmt.Fprintf(os.Stderr, "skipping route %s of %s, already served by %s\n", key, md.FullName(), prev)
+					continue
+				}
+				seen[key] = string(md.FullName())
+				t.routes = append(t.routes, rt)
+			}
+		}
+	}
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	dialer := func(context.Context, string) (net.Conn, error) { return lis.Dial() }
+	conn, err := grpc.Dial("bufconn", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("cannot dial in-process gRPC server: %w", err)
+	}
+	t.conn = conn
+	return t, nil
+}
+
+// Close closes the in-process connection to the gRPC server.
+func (t *transcoder) Close() error {
+	return t.conn.Close()
+}
+
+func newRoute(rule *annotations.HttpRule, md protoreflect.MethodDescriptor) (*route, error) {
+	var method, path string
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		method, path = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		method, path = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		method, path = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		method, path = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		method, path = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		method, path = p.Custom.GetKind(), p.Custom.GetPath()
+	default:
+		return nil, fmt.Errorf("missing HTTP pattern in rule of %s", md.FullName())
+	}
+	tmpl, err := parsePathTemplate(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP rule of %s: %w", md.FullName(), err)
+	}
+	return &route{httpMethod: method, tmpl: tmpl, rule: rule, md: md}, nil
+}
+
+// ServeHTTP answers requests without a matching route with 501 Not
+// Implemented.
+func (t *transcoder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, rt := range t.routes {
+		if rt.httpMethod != r.Method {
+			continue
+		}
+		if vars, ok := rt.tmpl.match(r.URL.Path); ok {
+			t.serve(w, r, rt, vars)
+			return
+		}
+	}
+	http.Error(w, r.URL.Path+": not Implemented", http.StatusNotImplemented)
+}
+
+func (t *transcoder) serve(w http.ResponseWriter, r *http.Request, rt *route, vars map[string]string) {
+	req, err := newRequest(r, rt, vars)
+	if err != nil {
+		writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	fullMethod := "/" + string(rt.md.Parent().FullName()) + "/" + string(rt.md.Name())
+	if !rt.md.IsStreamingServer() {
+		resp := dynamicpb.NewMessage(rt.md.Output())
+		if err := t.conn.Invoke(r.Context(), fullMethod, req, resp); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = writeResponse(w, rt, resp)
+		return
+	}
+	stream, err := t.conn.NewStream(r.Context(), &grpc.StreamDesc{ServerStreams: true}, fullMethod)
+	if err == nil {
+		err = stream.SendMsg(req)
+	}
+	if err == nil {
+		err = stream.CloseSend()
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	flusher, _ := w.(http.Flusher)
+	for {
+		resp := dynamicpb.NewMessage(rt.md.Output())
+		err := stream.RecvMsg(resp)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			// Headers are sent; report the error in the stream.
+			b, _ := protojson.Marshal(status.Convert(err).Proto())
+			fmt.Fprintf(w, "{\"error\":%s}\n", b)
+			return
+		}
+		if err := writeResponse(w, rt, resp); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// newRequest builds the request message from the body, the path
+// variables and, unless the whole body is mapped, the query parameters.
+func newRequest(r *http.Request, rt *route, vars map[string]string) (*dynamicpb.Message, error) {
+	req := dynamicpb.NewMessage(rt.md.Input())
+	if body := rt.rule.GetBody(); body != "" {
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read body: %w", err)
+		}
+		target := req.ProtoReflect()
+		if body != "*" {
+			fd := target.Descriptor().Fields().ByName(protoreflect.Name(body))
+			if fd == nil || fd.Message() == nil {
+				return nil, fmt.Errorf("body field %s is not a message field", body)
+			}
+			target = target.Mutable(fd).Message()
+		}
+		if len(b) != 0 {
+			if err := protojson.Unmarshal(b, target.Interface()); err != nil {
+				return nil, fmt.Errorf("cannot decode body: %w", err)
+			}
+		}
+	}
+	for path, value := range vars {
+		if err := setField(req, path, value); err != nil {
+			return nil, err
+		}
+	}
+	if rt.rule.GetBody() == "*" {
+		return req, nil
+	}
+	for path, values := range r.URL.Query() {
+		if _, ok := vars[path]; ok {
+			continue
+		}
+		for _, value := range values {
+			if err := setField(req, path, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return req, nil
+}
+
+// setField parses value into the field at the dot separated path of
+// field names, appending to repeated fields.
+func setField(m protoreflect.Message, path, value string) error {
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = m.Descriptor().Fields().ByJSONName(name)
+		}
+		if fd == nil {
+			return fmt.Errorf("unknown field %s in %s", name, m.Descriptor().FullName())
+		}
+		if i < len(names)-1 {
+			if fd.Message() == nil || fd.IsList() || fd.IsMap() {
+				return fmt.Errorf("field %s is not a singular message field", name)
+			}
+			m = m.Mutable(fd).Message()
+			continue
+		}
+		if fd.IsMap() || fd.Message() != nil {
+			return fmt.Errorf("cannot set field %s from a string", name)
+		}
+		v, err := parseScalar(fd, value)
+		if err != nil {
+			return fmt.Errorf("invalid value for field %s: %w", path, err)
+		}
+		if fd.IsList() {
+			m.Mutable(fd).List().Append(v)
+		} else {
+			m.Set(fd, v)
+		}
+	}
+	return nil
+}
+
+func parseScalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			b, err = base64.URLEncoding.DecodeString(s)
+		}
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported kind %s", fd.Kind())
+}
+
+// writeResponse writes resp, or its message field named by the rule's
+// response_body, as JSON line.
+func writeResponse(w io.Writer, rt *route, resp *dynamicpb.Message) error {
+	var m proto.Message = resp
+	if name := rt.rule.GetResponseBody(); name != "" {
+		if fd := rt.md.Output().Fields().ByName(protoreflect.Name(name)); fd != nil && fd.Message() != nil && !fd.IsList() && !fd.IsMap() {
+			m = resp.Get(fd).Message().Interface()
+		}
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	s := status.Convert(err)
+	b, _ := protojson.Marshal(s.Proto())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus(s.Code()))
+	_, _ = w.Write(append(b, '\n'))
+}
+
+// httpStatus maps gRPC codes to HTTP status codes as documented in
+// google/rpc/code.proto.
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// pathTemplate is a parsed google.api.http path template such as
+// /v1/{name=shelves/*}/books/{book}:verb.
+type pathTemplate struct {
+	segments []string // literals, * or **
+	vars     []pathVar
+	verb     string
+}
+
+// pathVar binds the template segments [start, end) to the field path.
+type pathVar struct {
+	field      string
+	start, end int
+}
+
+func parsePathTemplate(tmpl string) (*pathTemplate, error) {
+	if !strings.HasPrefix(tmpl, "/") {
+		return nil, fmt.Errorf("path template %q does not start with /", tmpl)
+	}
+	var tokens []string
+	depth, start := 0, 1
+	for i := 1; i < len(tmpl); i++ {
+		switch tmpl[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case '/':
+			if depth == 0 {
+				tokens = append(tokens, tmpl[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced braces in path template %q", tmpl)
+	}
+	last := tmpl[start:]
+	p := &pathTemplate{}
+	if i := strings.LastIndexByte(last, ':'); i >= 0 && i > strings.LastIndexByte(last, '}') {
+		last, p.verb = last[:i], last[i+1:]
+	}
+	tokens = append(tokens, last)
+	for _, tok := range tokens {
+		if !strings.HasPrefix(tok, "{") {
+			p.segments = append(p.segments, tok)
+			continue
+		}
+		if !strings.HasSuffix(tok, "}") {
+			return nil, fmt.Errorf("invalid variable %q in path template %q", tok, tmpl)
+		}
+		field, pattern := tok[1:len(tok)-1], "*"
+		if i := strings.IndexByte(field, '='); i >= 0 {
+			field, pattern = field[:i], field[i+1:]
+		}
+		v := pathVar{field: field, start: len(p.segments)}
+		p.segments = append(p.segments, strings.Split(pattern, "/")...)
+		v.end = len(p.segments)
+		p.vars = append(p.vars, v)
+	}
+	for i, seg := range p.segments {
+		if seg == "**" && i != len(p.segments)-1 {
+			return nil, fmt.Errorf("** must be the last segment in path template %q", tmpl)
+		}
+	}
+	return p, nil
+}
+
+// match returns the variable values of path if it matches the template.
+func (p *pathTemplate) match(path string) (map[string]string, bool) {
+	if p.verb != "" {
+		if !strings.HasSuffix(path, ":"+p.verb) {
+			return nil, false
+		}
+		path = strings.TrimSuffix(path, ":"+p.verb)
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, seg := range p.segments {
+		switch {
+		case seg == "**":
+			parts = append(parts[:i], strings.Join(parts[i:], "/"))
+		case i >= len(parts):
+			return nil, false
+		case seg == "*" && parts[i] == "":
+			return nil, false
+		case seg != "*" && seg != parts[i]:
+			return nil, false
+		}
+	}
+	if len(parts) != len(p.segments) {
+		return nil, false
+	}
+	vars := make(map[string]string, len(p.vars))
+	for _, v := range p.vars {
+		vars[v.field] = strings.Join(parts[v.start:v.end], "/")
+	}
+	return vars, true
+}
+
+func (p *pathTemplate) String() string {
+	s := "/" + strings.Join(p.segments, "/")
+	if p.verb != "" {
+		s += ":" + p.verb
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+// convertCmd extracts a FileDescriptorSet from its input. Converting a
+// message between formats unchanged is done by decode-descriptor.
+type convertCmd struct {
+	descriptorInput `embed:""`
+}
+
+func (c *convertCmd) Run(g globals) error {
+	m, err := c.decode(g)
+	if err != nil {
+		return err
+	}
+	fds, err := toFileDescriptorSet(m, g)
+	if err != nil {
+		return err
+	}
+	return printProto(fds, g)
+}
+
+// toFileDescriptorSet wraps a FileDescriptorProto or the files of a
+// ServerReflectionResponse in a FileDescriptorSet.
+func toFileDescriptorSet(m protoreflect.ProtoMessage, g globals) (*dpb.FileDescriptorSet, error) {
+	switch m := m.(type) {
+	case *dpb.FileDescriptorSet:
+		return m, nil
+	case *dpb.FileDescriptorProto:
+		return &dpb.FileDescriptorSet{File: []*dpb.FileDescriptorProto{m}}, nil
+	case *rpb.ServerReflectionResponse:
+		if e := m.GetErrorResponse(); e != nil {
+			return nil, errors.Errorf("cannot extract files from error response: %s", e.GetErrorMessage())
+		}
+		if m.GetFileDescriptorResponse() == nil {
+			return nil, errors.New("cannot extract files: not a file descriptor response")
+		}
+		fds := &dpb.FileDescriptorSet{}
+		for _, b := range m.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fdp := &dpb.FileDescriptorProto{}
+			if err := unmarshal(b, fdp, g); err != nil {
+				return nil, errors.Wrap(err, "cannot decode file descriptor")
+			}
+			fds.File = append(fds.File, fdp)
+		}
+		return fds, nil
+	}
+	return nil, errors.Errorf("cannot convert %T to FileDescriptorSet", m)
+}
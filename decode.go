@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+type decodeDescriptorCmd struct {
+	descriptorInput `embed:""`
+}
+
+// descriptorInput reads a FileDescriptorSet, FileDescriptorProto or
+// ServerReflectionResponse in any supported format.
+type descriptorInput struct {
+	Input    string `arg:"" optional:"" help:"input file, - for stdin, or inline value" default:"-"`
+	InFormat string `help:"input format, one of auto, base64, bin, json, text" enum:"auto,base64,bin,json,text" default:"auto"`
+	Type     string `help:"input message type, one of auto, fds (FileDescriptorSet), fd (FileDescriptorProto), response (ServerReflectionResponse)" enum:"auto,fds,fd,response" default:"auto"`
+}
+
+func (d *decodeDescriptorCmd) Run(g globals) error {
+	m, err := d.decode(g)
+	if err != nil {
+		return err
+	}
+	return printProto(m, g)
+}
+
+func (d *descriptorInput) decode(g globals) (protoreflect.ProtoMessage, error) {
+	b, err := readArg(d.Input)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDescriptor(b, d.InFormat, d.Type, g)
+}
+
+// readArg returns the contents of stdin for "-", the contents of the file
+// named arg if it exists, or arg itself.
+func readArg(arg string) ([]byte, error) {
+	if arg == "-" {
+		return readInput("", arg)
+	}
+	if fi, err := os.Stat(arg); err == nil && fi.Mode().IsRegular() {
+		return readInput("", arg)
+	}
+	return []byte(arg), nil
+}
+
+// decodeDescriptor decodes b as FileDescriptorSet, FileDescriptorProto or
+// ServerReflectionResponse. For "auto" format or type, every candidate is
+// tried in turn and the first one that decodes cleanly is returned.
+func decodeDescriptor(b []byte, format, typ string, g globals) (protoreflect.ProtoMessage, error) {
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil, errors.New("cannot decode empty input")
+	}
+	formats := []string{format}
+	if format == "auto" {
+		formats = detectFormats(b)
+	}
+	types := []string{typ}
+	if typ == "auto" {
+		types = []string{"response", "fds", "fd"}
+	}
+	for _, f := range formats {
+		for _, t := range types {
+			m := newDescriptorMessage(t)
+			if err := unmarshalFormat(b, m, f, g); err != nil {
+				continue
+			}
+			if typ != "auto" || plausible(m) {
+				return m, nil
+			}
+		}
+	}
+	return nil, errors.New("cannot decode input as descriptor or reflection response")
+}
+
+// detectFormats returns the formats b may be encoded in, most specific
+// first.
+func detectFormats(b []byte) []string {
+	s := bytes.TrimSpace(b)
+	var formats []string
+	if s[0] == '{' {
+		formats = append(formats, "json")
+	}
+	if _, err := base64.StdEncoding.DecodeString(string(s)); err == nil {
+		formats = append(formats, "base64")
+	}
+	if utf8.Valid(s) {
+		formats = append(formats, "text")
+	}
+	return append(formats, "bin")
+}
+
+func newDescriptorMessage(typ string) protoreflect.ProtoMessage {
+	switch typ {
+	case "fds":
+		return &dpb.FileDescriptorSet{}
+	case "fd":
+		return &dpb.FileDescriptorProto{}
+	}
+	return &rpb.ServerReflectionResponse{}
+}
+
+// plausible reports whether m looks like a correctly typed decoding: it
+// has no unknown fields and contains the data its type requires.
+func plausible(m protoreflect.ProtoMessage) bool {
+	if hasUnknown(m.ProtoReflect()) {
+		return false
+	}
+	switch m := m.(type) {
+	case *dpb.FileDescriptorSet:
+		for _, f := range m.File {
+			if f.GetName() == "" {
+				return false
+			}
+		}
+		return len(m.File) > 0
+	case *dpb.FileDescriptorProto:
+		return m.GetName() != ""
+	case *rpb.ServerReflectionResponse:
+		return m.MessageResponse != nil
+	}
+	return false
+}
+
+func hasUnknown(m protoreflect.Message) bool {
+	if len(m.GetUnknown()) > 0 {
+		return true
+	}
+	found := false
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Message() == nil || fd.IsMap():
+		case fd.IsList():
+			for i := 0; i < v.List().Len() && !found; i++ {
+				found = hasUnknown(v.List().Get(i).Message())
+			}
+		default:
+			found = hasUnknown(v.Message())
+		}
+		return !found
+	})
+	return found
+}
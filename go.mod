@@ -6,9 +6,10 @@ require (
 	github.com/alecthomas/kong v0.2.16
 	github.com/golang/protobuf v1.5.0
 	github.com/pkg/errors v0.8.1
+	github.com/soheilhy/cmux v0.1.5
 	github.com/stretchr/testify v1.7.0
-	golang.org/x/net v0.0.0-20190311183353-d8887717615a
 	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
 	google.golang.org/grpc v1.37.1
 	google.golang.org/protobuf v1.26.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
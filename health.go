@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type healthCmd struct {
+	Service string `arg:"" optional:"" help:"service to check, default: overall server health"`
+	Watch   bool   `help:"Stream status changes until the server closes the stream"`
+}
+
+// exitCoder is implemented by errors that set a specific exit code.
+type exitCoder interface {
+	ExitCode() int
+}
+
+// statusError is returned for every health status but SERVING.
+type statusError struct {
+	status healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (e statusError) Error() string {
+	return "health status " + e.status.String()
+}
+
+// ExitCode maps the health status to the exit code: 2 for NOT_SERVING,
+// 3 for SERVICE_UNKNOWN and 4 for UNKNOWN. Other errors exit with 1.
+func (e statusError) ExitCode() int {
+	switch e.status {
+	case healthpb.HealthCheckResponse_NOT_SERVING:
+		return 2
+	case healthpb.HealthCheckResponse_SERVICE_UNKNOWN:
+		return 3
+	}
+	return 4
+}
+
+func (h *healthCmd) Run(g globals) error {
+	conn, err := dial(g)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+	req := &healthpb.HealthCheckRequest{Service: h.Service}
+	if !h.Watch {
+		resp, err := client.Check(context.Background(), req)
+		if status.Code(err) == codes.NotFound {
+			resp, err = &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN}, nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "cannot check health")
+		}
+		if err := printProto(resp, g); err != nil {
+			return err
+		}
+		return checkStatus(resp.GetStatus())
+	}
+	stream, err := client.Watch(context.Background(), req)
+	if err != nil {
+		return errors.Wrap(err, "cannot watch health")
+	}
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return checkStatus(last)
+		}
+		if err != nil {
+			return errors.Wrap(err, "cannot receive health status")
+		}
+		if err := printProto(resp, g); err != nil {
+			return err
+		}
+		if g.Template == "" && g.Field == "" {
+			if err := endMessage(g); err != nil {
+				return err
+			}
+		}
+		last = resp.GetStatus()
+	}
+}
+
+func checkStatus(s healthpb.HealthCheckResponse_ServingStatus) error {
+	if s != healthpb.HealthCheckResponse_SERVING {
+		return statusError{status: s}
+	}
+	return nil
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
-	"context"
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/alecthomas/kong"
+	"github.com/juliaogris/reflect/pkg/reflectclient"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
@@ -15,32 +17,65 @@ import (
 	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 	dpb "google.golang.org/protobuf/types/descriptorpb"
+	"gopkg.in/yaml.v3"
 )
 
 var version = "v0.0.0"
 
 type globals struct {
-	Address   string `short:"a" env:"GRPC_ADDRESS" help:"gRPC server address"`
+	Address   string `kong:"-"` // single target of config.Addresses
 	Plaintext bool   `short:"p" help:"Use plain-text; no TLS" env:"GRPC_PLAINTEXT"`
-	Format    string `short:"f" help:"output protoset as one of json, base64, bin, text" enum:"json,base64,bin,text" default:"json"`
+	CACert    string `name:"cacert" help:"CA certificate file to verify the server with, default: system roots" type:"existingfile"`
+	Cert      string `help:"client certificate file for mutual TLS" type:"existingfile"`
+	Key       string `help:"client private key file for mutual TLS" type:"existingfile"`
+	Format    string `short:"f" help:"output protoset as one of json, base64, bin, text, yaml, table" enum:"json,base64,bin,text,yaml,table" default:"json"`
 	Out       string `short:"o" help:"output file, default: stdout" default:"-"`
+	Stable    bool   `help:"Deterministic output for golden files: sorted, normalized and without valid_host"`
+	Template  string `help:"Go template to print the response with, fields by Go name, e.g. {{.ListServicesResponse}}" xor:"select"`
+	Field     string `help:"Print only the values at dot separated proto field path, e.g. list_services_response.service.name" xor:"select"`
+	NoHeaders bool   `help:"Omit column headers in table format"`
+	Wide      bool   `help:"Print additional columns in table format"`
+
+	AnyResolver string `help:"source of types for expanding Any payloads and extensions: one of server, protoset, none" enum:"server,protoset,none" default:"server"`
+	Protoset    string `help:"FileDescriptorSet file used by --any-resolver=protoset" type:"existingfile"`
+
+	Cache    bool          `help:"Cache file descriptors fetched for lookups on disk"`
+	CacheDir string        `help:"descriptor cache directory, default: reflect in the user cache directory" type:"path"`
+	CacheTTL time.Duration `help:"time to live of cached file descriptors" default:"24h"`
 
 	out         io.Writer
-	hostAddress string // used in tests to work with localhost:0
+	hostAddress string   // used in tests to work with localhost:0
+	authority   string   // overrides the :authority of backends, see runBackends
+	resolver    resolver // nil uses the types linked into the binary
 }
 
 type config struct {
 	globals
-	Version    kong.VersionFlag `short:"V" help:"Print version information" group:"Other:"`
-	Services   servicesCmd      `cmd:"" help:"Call list_services"`
-	Symbol     symbolCmd        `cmd:"" help:"Call file_containing_symbol"`
-	Filename   filenameCmd      `cmd:"" help:"Call file_by_filename"`
-	Extension  extensionCmd     `cmd:"" help:"Call file_containing_extension"`
-	Extensions extensionsCmd    `cmd:"" help:"Call all_extension_numbers_of_type"`
-	FDS        fdsCmd           `cmd:"" help:"Decode base64 encoded FileDescriptorSet"`
-	FD         fdCmd            `cmd:"" help:"Decode base64 encoded FileDescriptor"`
-	FDSF       fdsfCmd          `cmd:"" help:"Decode proto encoded FileDescriptorSet.pb file"`
+	Addresses   []string `name:"address" short:"a" env:"GRPC_ADDRESS" help:"gRPC server address, repeat or comma separate for several"`
+	TargetsFile string   `help:"file with one gRPC server address per line" type:"existingfile"`
+	Concurrency int      `help:"maximum number of servers queried concurrently" default:"8"`
+	EachBackend bool     `help:"Resolve the DNS name of --address and query every backend IP separately, grouped by schema fingerprint"`
+
+	Version    kong.VersionFlag    `short:"V" help:"Print version information" group:"Other:"`
+	Services   servicesCmd         `cmd:"" help:"Call list_services"`
+	Symbol     symbolCmd           `cmd:"" help:"Call file_containing_symbol"`
+	Filename   filenameCmd         `cmd:"" help:"Call file_by_filename"`
+	Extension  extensionCmd        `cmd:"" help:"Call file_containing_extension"`
+	Extensions extensionsCmd       `cmd:"" help:"Call all_extension_numbers_of_type"`
+	Raw        rawCmd              `cmd:"" help:"Send ServerReflectionRequest as given"`
+	Decode     decodeDescriptorCmd `cmd:"" name:"decode-descriptor" help:"Decode FileDescriptorSet, FileDescriptorProto or ServerReflectionResponse in any format"`
+	Convert    convertCmd          `cmd:"" help:"Extract a FileDescriptorSet from descriptors and reflection responses in any format"`
+	Health     healthCmd           `cmd:"" help:"Call grpc.health.v1.Health Check or Watch"`
+	Channelz   channelzCmd         `cmd:"" help:"Query grpc.channelz.v1 for the server's view of servers, channels and sockets"`
+	Watch      watchCmd            `cmd:"" help:"Poll server and print an event whenever its schema changes"`
+	Cache      cacheCmd            `cmd:"" help:"Manage the descriptor cache"`
+	FDS        fdsCmd              `cmd:"" help:"Decode base64 encoded FileDescriptorSet"`
+	FD         fdCmd               `cmd:"" help:"Decode base64 encoded FileDescriptor"`
+	FDSF       fdsfCmd             `cmd:"" help:"Decode proto encoded FileDescriptorSet.pb file"`
+
+	targets []string
 }
 
 type servicesCmd struct{}
@@ -59,7 +94,14 @@ type extensionCmd struct {
 }
 
 type extensionsCmd struct {
-	Type string `arg:""`
+	Type    string `arg:""`
+	Resolve bool   `help:"Print a sorted table of extension number, name, type and defining file"`
+}
+
+type rawCmd struct {
+	Request  string `arg:"" optional:"" help:"ServerReflectionRequest, default: read from --in"`
+	In       string `short:"i" help:"input file, - for stdin" default:"-"`
+	InFormat string `help:"input format, one of json, text, bin" enum:"json,text,bin" default:"json"`
 }
 
 type fdCmd struct {
@@ -80,7 +122,26 @@ func main() {
 		kong.Vars{"version": version},
 		kong.Description("gRPC reflection API toolkit"),
 	)
-	err := kctx.Run(cfg.globals)
+	var err error
+	target := kctx.Selected().Target.Addr().Interface()
+	if (cfg.EachBackend || len(cfg.targets) > 1) && !localCmd(target) {
+		cmd, ok := multiRunner(target)
+		if !ok {
+			kctx.Fatalf("cannot run %s against several servers", kctx.Command())
+		}
+		if cfg.EachBackend {
+			err = runBackends(cmd, cfg.globals, cfg.Concurrency)
+		} else {
+			err = runTargets(cmd, cfg.globals, cfg.targets, cfg.Concurrency)
+		}
+	} else {
+		err = kctx.Run(cfg.globals)
+	}
+	cfg.closeResolver()
+	if e, ok := errors.Cause(err).(exitCoder); ok {
+		kctx.Errorf("%s", err)
+		kctx.Exit(e.ExitCode())
+	}
 	kctx.FatalIfErrorf(err)
 }
 
@@ -92,8 +153,35 @@ func (cfg *config) AfterApply() error {
 			return errors.Wrap(err, "cannot create output file")
 		}
 	}
+	cfg.targets = cfg.Addresses
+	if cfg.TargetsFile != "" {
+		targets, err := readTargets(cfg.TargetsFile)
+		if err != nil {
+			return err
+		}
+		cfg.targets = append(cfg.targets, targets...)
+	}
+	if len(cfg.targets) == 1 {
+		cfg.Address = cfg.targets[0]
+	}
+	if cfg.EachBackend && len(cfg.targets) != 1 {
+		return errors.New("--each-backend requires a single --address")
+	}
 	cfg.hostAddress = cfg.Address
-	return nil
+	return cfg.setResolver()
+}
+
+func (g *globals) setResolver() error {
+	var err error
+	g.resolver, err = newResolver(*g)
+	return err
+}
+
+// closeResolver closes the connection of the any resolver, if any.
+func (g globals) closeResolver() {
+	if r, ok := g.resolver.(*typeRegistry); ok {
+		_ = r.Close()
+	}
 }
 
 func (f *fdCmd) Run(g globals) error {
@@ -108,11 +196,11 @@ func (f *fdsCmd) Run(g globals) error {
 
 func (f *fdsfCmd) Run(g globals) error {
 	m := &dpb.FileDescriptorSet{}
-	err := proto.Unmarshal(f.FileDescriptorSetFile, m)
+	err := unmarshal(f.FileDescriptorSetFile, m, g)
 	if err != nil {
 		return errors.Wrap(err, "cannot decode proto message")
 	}
-	return printProto(g.out, m, g.Format)
+	return printProto(m, g)
 }
 
 func decode(b64 string, m protoreflect.ProtoMessage, g globals) error {
@@ -120,11 +208,22 @@ func decode(b64 string, m protoreflect.ProtoMessage, g globals) error {
 	if err != nil {
 		return errors.Wrap(err, "cannot decode b64 string")
 	}
-	err = proto.Unmarshal(b, m)
+	err = unmarshal(b, m, g)
 	if err != nil {
 		return errors.Wrap(err, "cannot decode proto message")
 	}
-	return printProto(g.out, m, g.Format)
+	return printProto(m, g)
+}
+
+func unmarshal(b []byte, m protoreflect.ProtoMessage, g globals) error {
+	return proto.UnmarshalOptions{Resolver: g.typeResolver()}.Unmarshal(b, m)
+}
+
+func (g globals) typeResolver() resolver {
+	if g.resolver == nil {
+		return protoregistry.GlobalTypes
+	}
+	return g.resolver
 }
 
 func (s *servicesCmd) Run(g globals) error {
@@ -175,84 +274,173 @@ func (e *extensionsCmd) Run(g globals) error {
 			AllExtensionNumbersOfType: e.Type,
 		},
 	}
-	return run(req, g)
+	if !e.Resolve {
+		return run(req, g)
+	}
+	c, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	r := newTypeRegistry(withCache(&serverFetcher{g: g, client: c}, g))
+	defer r.Close()
+	resp, err := c.Send(req)
+	if err != nil {
+		return err
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return errors.Errorf("cannot get extension numbers: %s", errResp.GetErrorMessage())
+	}
+	g.resolver = r
+	return printTable(resp, g)
 }
 
-func run(req *rpb.ServerReflectionRequest, g globals) error {
-	stream, err := newStream(context.Background(), g)
+func (r *rawCmd) Run(g globals) error {
+	b, err := readInput(r.Request, r.In)
 	if err != nil {
 		return err
 	}
-	defer closeAndDrain(stream)
-	resp, err := send(stream, req)
-	if err != nil {
+	req := &rpb.ServerReflectionRequest{}
+	if err := unmarshalFormat(b, req, r.InFormat, g); err != nil {
 		return err
 	}
+	return run(req, g)
+}
 
-	return printProto(g.out, resp, g.Format)
+// readInput returns arg if it is set and otherwise the contents of
+// filename, where "-" reads stdin.
+func readInput(arg, filename string) ([]byte, error) {
+	if arg != "" {
+		return []byte(arg), nil
+	}
+	var b []byte
+	var err error
+	if filename == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(filename)
+	}
+	return b, errors.Wrap(err, "cannot read input")
 }
 
-func closeAndDrain(stream rpb.ServerReflection_ServerReflectionInfoClient) {
-	_ = stream.CloseSend()
-	for {
-		if _, err := stream.Recv(); err != nil {
-			return
+func unmarshalFormat(b []byte, m protoreflect.ProtoMessage, format string, g globals) error {
+	var err error
+	switch format {
+	case "json":
+		err = protojson.UnmarshalOptions{Resolver: g.typeResolver()}.Unmarshal(b, m)
+	case "text":
+		err = prototext.UnmarshalOptions{Resolver: g.typeResolver()}.Unmarshal(b, m)
+	case "bin":
+		err = unmarshal(b, m, g)
+	case "base64":
+		b, err = base64.StdEncoding.DecodeString(string(bytes.TrimSpace(b)))
+		if err == nil {
+			err = unmarshal(b, m, g)
 		}
+	default:
+		err = fmt.Errorf("unknown format %s", format)
 	}
+	return errors.Wrapf(err, "cannot decode %s input", format)
 }
 
-func newStream(ctx context.Context, g globals) (rpb.ServerReflection_ServerReflectionInfoClient, error) {
+func run(req *rpb.ServerReflectionRequest, g globals) error {
+	c, err := newClient(g)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	resp, err := c.Send(req)
+	if err != nil {
+		return err
+	}
+
+	return printProto(resp, g)
+}
+
+// dialOptions returns the transport security and authority options of
+// connections to g.Address.
+func (g globals) dialOptions() ([]grpc.DialOption, error) {
 	var opts []grpc.DialOption
 	if g.Plaintext {
 		opts = append(opts, grpc.WithInsecure())
+	} else {
+		creds, err := g.transportCredentials()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
 	}
-	conn, err := grpc.Dial(g.Address, opts...)
+	if g.authority != "" {
+		opts = append(opts, grpc.WithAuthority(g.authority))
+	}
+	return opts, nil
+}
+
+// newClient returns a reflection client for g.Address; its stream is
+// opened on first use.
+func newClient(g globals) (*reflectclient.Client, error) {
+	opts, err := g.dialOptions()
 	if err != nil {
-		return nil, errors.Wrapf(err, "cannot grpc dial %s", g.Address)
+		return nil, err
 	}
-	client := rpb.NewServerReflectionClient(conn)
-	stream, err := client.ServerReflectionInfo(ctx)
+	return reflectclient.Dial(g.Address, reflectclient.WithDialOptions(opts...), reflectclient.WithHost(g.hostAddress))
+}
+
+func dial(g globals) (*grpc.ClientConn, error) {
+	opts, err := g.dialOptions()
 	if err != nil {
-		return nil, errors.Wrap(err, "cannot setup reflection stream")
+		return nil, err
 	}
-	return stream, nil
+	conn, err := grpc.Dial(g.Address, opts...)
+	return conn, errors.Wrapf(err, "cannot grpc dial %s", g.Address)
 }
 
-func send(stream rpb.ServerReflection_ServerReflectionInfoClient, req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
-	if err := stream.Send(req); err != nil {
-		return nil, errors.Wrap(err, "cannot send reflection request")
+func printProto(m protoreflect.ProtoMessage, g globals) error {
+	if g.Stable {
+		m = stabilize(m)
 	}
-	resp, err := stream.Recv()
-	if err != nil {
-		return nil, errors.Wrap(err, "cannot receive reflection response")
+	switch {
+	case g.Template != "":
+		return printTemplate(m, g.Template, g)
+	case g.Field != "":
+		return printField(m, g.Field, g)
 	}
-	return resp, nil
+	return printMessage(m, g)
 }
 
-func printProto(w io.Writer, m protoreflect.ProtoMessage, format string) error {
+func printMessage(m protoreflect.ProtoMessage, g globals) error {
 	var b []byte
 	var err error
-	switch format {
+	switch g.Format {
 	case "json":
-		b, err = jsonString(m)
+		b, err = jsonString(m, g.typeResolver())
+		if err == nil && g.Stable {
+			b, err = normalizeJSON(b)
+		}
 	case "base64":
-		b, err = base64String(m)
+		b, err = base64String(m, g.Stable)
 	case "text":
-		b, err = textString(m)
+		b, err = textString(m, g.typeResolver())
+		if err == nil && g.Stable {
+			b = normalizeText(b)
+		}
 	case "bin":
-		b, err = binString(m)
+		b, err = binString(m, g.Stable)
+	case "yaml":
+		b, err = yamlString(m, g.typeResolver())
+	case "table":
+		return printTable(m, g)
 	default:
-		err = fmt.Errorf("unknown format %s", format)
+		err = fmt.Errorf("unknown format %s", g.Format)
 	}
 	if err != nil {
 		return err
 	}
-	_, err = w.Write(b)
+	_, err = g.out.Write(b)
 	return errors.Wrap(err, "cannot print proto")
 }
 
-func jsonString(m protoreflect.ProtoMessage) ([]byte, error) {
-	marshaler := protojson.MarshalOptions{Multiline: true}
+func jsonString(m protoreflect.ProtoMessage, r resolver) ([]byte, error) {
+	marshaler := protojson.MarshalOptions{Multiline: true, Resolver: r}
 	out, err := marshaler.Marshal(m)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot jsonString")
@@ -260,24 +448,53 @@ func jsonString(m protoreflect.ProtoMessage) ([]byte, error) {
 	return out, nil
 }
 
-func base64String(m protoreflect.ProtoMessage) ([]byte, error) {
-	b, err := proto.Marshal(m)
+// yamlString converts the protojson encoding of m to block style YAML,
+// keeping protojson's key order.
+func yamlString(m protoreflect.ProtoMessage, r resolver) ([]byte, error) {
+	b, err := protojson.MarshalOptions{Resolver: r}.Marshal(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot yamlString")
+	}
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(b, node); err != nil {
+		return nil, errors.Wrap(err, "cannot yamlString")
+	}
+	clearStyle(node)
+	out := &bytes.Buffer{}
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		return nil, errors.Wrap(err, "cannot yamlString")
+	}
+	return out.Bytes(), nil
+}
+
+func clearStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearStyle(c)
+	}
+}
+
+func base64String(m protoreflect.ProtoMessage, deterministic bool) ([]byte, error) {
+	b, err := proto.MarshalOptions{Deterministic: deterministic}.Marshal(m)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot base64String")
 	}
 	return []byte(base64.StdEncoding.EncodeToString(b)), nil
 }
 
-func binString(m protoreflect.ProtoMessage) ([]byte, error) {
-	out, err := proto.Marshal(m)
+func binString(m protoreflect.ProtoMessage, deterministic bool) ([]byte, error) {
+	out, err := proto.MarshalOptions{Deterministic: deterministic}.Marshal(m)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot binString")
 	}
 	return out, nil
 }
 
-func textString(m protoreflect.ProtoMessage) ([]byte, error) {
-	out, err := prototext.Marshal(m)
+func textString(m protoreflect.ProtoMessage, r resolver) ([]byte, error) {
+	marshaler := prototext.MarshalOptions{Resolver: r}
+	out, err := marshaler.Marshal(m)
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot textString")
 	}
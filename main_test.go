@@ -1,26 +1,57 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
 	_ "embed"
 	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"io"
+	"math/big"
 	"net"
 	"os"
 	"path"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/juliaogris/reflect/pkg/dynamictypes"
 	"github.com/juliaogris/reflect/pkg/echo2"
 	"github.com/juliaogris/reflect/pkg/echo3"
+	"github.com/juliaogris/reflect/pkg/faults"
+	"github.com/juliaogris/reflect/pkg/reflectserver"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
+	channelzpb "google.golang.org/grpc/channelz/grpc_channelz_v1"
+	channelz "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"gopkg.in/yaml.v3"
 )
 
 func TestFileDescriptorCmd(t *testing.T) {
@@ -189,6 +220,734 @@ func TestFileDescriptorSetCmd(t *testing.T) {
 	require.JSONEq(t, want, b.String())
 }
 
+func TestDecodeDescriptor(t *testing.T) {
+	fdp := protodesc.ToFileDescriptorProto(anypb.File_google_protobuf_any_proto)
+	fds := &dpb.FileDescriptorSet{File: []*dpb.FileDescriptorProto{fdp}}
+	fdsBin, err := proto.Marshal(fds)
+	require.NoError(t, err)
+	fdBin, err := proto.Marshal(fdp)
+	require.NoError(t, err)
+	fdText, err := prototext.Marshal(fdp)
+	require.NoError(t, err)
+	fdsJSON, err := protojson.Marshal(fds)
+	require.NoError(t, err)
+	respBin, err := os.ReadFile("testdata/proto3-bin/TestFilenameCmd.bin")
+	require.NoError(t, err)
+	respJSON, err := os.ReadFile("testdata/proto3-json/TestServicesCmd.json")
+	require.NoError(t, err)
+	respBase64, err := os.ReadFile("testdata/proto2-base64/TestSymbolCmd.base64")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		in   []byte
+		want string
+	}{
+		"fds bin":         {in: fdsBin, want: "google.protobuf.FileDescriptorSet"},
+		"fds base64":      {in: []byte(base64.StdEncoding.EncodeToString(fdsBin)), want: "google.protobuf.FileDescriptorSet"},
+		"fds json":        {in: fdsJSON, want: "google.protobuf.FileDescriptorSet"},
+		"fd bin":          {in: fdBin, want: "google.protobuf.FileDescriptorProto"},
+		"fd base64":       {in: []byte(base64.StdEncoding.EncodeToString(fdBin)), want: "google.protobuf.FileDescriptorProto"},
+		"fd text":         {in: fdText, want: "google.protobuf.FileDescriptorProto"},
+		"response bin":    {in: respBin, want: "grpc.reflection.v1alpha.ServerReflectionResponse"},
+		"response json":   {in: respJSON, want: "grpc.reflection.v1alpha.ServerReflectionResponse"},
+		"response base64": {in: respBase64, want: "grpc.reflection.v1alpha.ServerReflectionResponse"},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			m, err := decodeDescriptor(tc.in, "auto", "auto", globals{})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, string(proto.MessageName(m)))
+		})
+	}
+
+	_, err = decodeDescriptor([]byte(" "), "auto", "auto", globals{})
+	require.Error(t, err)
+	_, err = decodeDescriptor([]byte("not a descriptor"), "auto", "auto", globals{})
+	require.Error(t, err)
+	_, err = decodeDescriptor(fdBin, "json", "fd", globals{})
+	require.Error(t, err)
+}
+
+func TestDecodeDescriptorCmd(t *testing.T) {
+	b := &bytes.Buffer{}
+	g := globals{Format: "json", out: b}
+	cmd := decodeDescriptorCmd{descriptorInput{
+		Input:    "testdata/proto3-base64/TestExtensionCmd.base64",
+		InFormat: "auto",
+		Type:     "auto",
+	}}
+	err := cmd.Run(g)
+	require.NoError(t, err)
+	want, err := os.ReadFile("testdata/proto3-json/TestExtensionCmd.json")
+	require.NoError(t, err)
+	require.JSONEq(t, string(want), b.String())
+}
+
+func TestConvertCmd(t *testing.T) {
+	b := &bytes.Buffer{}
+	g := globals{Format: "bin", out: b}
+	in := descriptorInput{
+		Input:    "testdata/proto3-json/TestFilenameCmd.json",
+		InFormat: "auto",
+		Type:     "auto",
+	}
+	cmd := convertCmd{descriptorInput: in}
+	err := cmd.Run(g)
+	require.NoError(t, err)
+
+	fds := &dpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(b.Bytes(), fds))
+	var got []string
+	for _, f := range fds.File {
+		got = append(got, f.GetName())
+	}
+	want := []string{"echo3/echo3.proto", "google/api/annotations.proto", "google/protobuf/any.proto", "google/api/http.proto", "google/protobuf/descriptor.proto"}
+	require.ElementsMatch(t, want, got)
+
+	in.Input = "testdata/proto3-json/TestFilenameCmdErr.json"
+	cmd = convertCmd{descriptorInput: in}
+	err = cmd.Run(g)
+	require.Error(t, err)
+
+	in.Input = "testdata/proto3-json/TestServicesCmd.json"
+	cmd = convertCmd{descriptorInput: in}
+	err = cmd.Run(g)
+	require.Error(t, err)
+}
+
+func TestNormalizeText(t *testing.T) {
+	in := `name:"a  \"b  c"  number:1 options:{deprecated:true}  label:LABEL_OPTIONAL`
+	want := `name:"a  \"b  c" number:1 options:{deprecated:true} label:LABEL_OPTIONAL`
+	require.Equal(t, want, string(normalizeText([]byte(in))))
+
+	fdp := &dpb.FieldDescriptorProto{
+		Name:    proto.String("a  b"),
+		Number:  proto.Int32(1),
+		Label:   dpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:    dpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		Options: &dpb.FieldOptions{Deprecated: proto.Bool(true)},
+	}
+	b := &bytes.Buffer{}
+	require.NoError(t, printMessage(fdp, globals{Format: "text", Stable: true, out: b}))
+	require.Equal(t, `name:"a  b" number:1 label:LABEL_OPTIONAL type:TYPE_STRING options:{deprecated:true}`, b.String())
+}
+
+func TestPrintFieldEmptyList(t *testing.T) {
+	b := &bytes.Buffer{}
+	g := globals{out: b}
+	err := printField(&dpb.FileDescriptorSet{}, "file.name", g)
+	require.NoError(t, err)
+	require.Empty(t, b.String())
+	err = printField(&dpb.FileDescriptorSet{}, "file.MISSING", g)
+	require.Error(t, err)
+
+	resp := &rpb.ServerReflectionResponse{
+		MessageResponse: &rpb.ServerReflectionResponse_ErrorResponse{
+			ErrorResponse: &rpb.ErrorResponse{ErrorCode: 5, ErrorMessage: "not found"},
+		},
+	}
+	err = printField(resp, "list_services_response.service.name", g)
+	require.NoError(t, err)
+	require.Empty(t, b.String())
+	err = printField(resp, "list_services_response.service.MISSING", g)
+	require.Error(t, err)
+}
+
+func TestPrintTemplateRecursive(t *testing.T) {
+	fdp := &dpb.FileDescriptorProto{}
+	err := prototext.Unmarshal([]byte(`
+		name: "node.proto"
+		syntax: "proto3"
+		message_type {
+			name: "Node"
+			field { name: "name" number: 1 type: TYPE_STRING label: LABEL_OPTIONAL }
+			field { name: "next" number: 2 type: TYPE_MESSAGE type_name: ".Node" label: LABEL_OPTIONAL }
+		}`), fdp)
+	require.NoError(t, err)
+	fd, err := protodesc.NewFile(fdp, nil)
+	require.NoError(t, err)
+	md := fd.Messages().ByName("Node")
+	next := dynamicpb.NewMessage(md)
+	next.Set(md.Fields().ByName("name"), protoreflect.ValueOfString("b"))
+	m := dynamicpb.NewMessage(md)
+	m.Set(md.Fields().ByName("name"), protoreflect.ValueOfString("a"))
+	m.Set(md.Fields().ByName("next"), protoreflect.ValueOfMessage(next))
+
+	b := &bytes.Buffer{}
+	g := globals{out: b}
+	err = printTemplate(m, `{{.Name}} {{.Next.Name}} {{.Next.Next.Name}}|{{len .Next.Next}}`, g)
+	require.NoError(t, err)
+	require.Equal(t, "a b <no value>|0", b.String())
+}
+
+func TestFileTable(t *testing.T) {
+	b := &bytes.Buffer{}
+	g := globals{Format: "table", out: b, Wide: true}
+	fdp := protodesc.ToFileDescriptorProto(echo3.File_echo3_echo3_proto)
+	err := printProto(fdp, g)
+	require.NoError(t, err)
+	want := `FILE              PACKAGE MESSAGES ENUMS SERVICES SYNTAX DEPENDENCIES
+echo3/echo3.proto echo3   7        1     1        proto3 google/api/annotations.proto,google/protobuf/any.proto
+`
+	require.Equal(t, want, b.String())
+
+	err = printProto(&dpb.DescriptorProto{}, g)
+	require.Error(t, err)
+}
+
+func TestMultiRunner(t *testing.T) {
+	_, ok := multiRunner(&servicesCmd{})
+	require.True(t, ok)
+	_, ok = multiRunner(&watchCmd{})
+	require.False(t, ok)
+	_, ok = multiRunner(&healthCmd{})
+	require.True(t, ok)
+	_, ok = multiRunner(&healthCmd{Watch: true})
+	require.False(t, ok)
+	_, ok = multiRunner(&channelzServersCmd{})
+	require.True(t, ok)
+	_, ok = multiRunner(&rawCmd{In: "-"})
+	require.False(t, ok)
+	_, ok = multiRunner(&rawCmd{Request: "{}", In: "-"})
+	require.True(t, ok)
+	_, ok = multiRunner(&rawCmd{In: "request.json"})
+	require.True(t, ok)
+	_, ok = multiRunner(&decodeDescriptorCmd{})
+	require.False(t, ok)
+	_, ok = multiRunner(&cacheLsCmd{})
+	require.False(t, ok)
+	_, ok = multiRunner(&struct{}{})
+	require.False(t, ok)
+}
+
+func TestLocalCmd(t *testing.T) {
+	require.True(t, localCmd(&decodeDescriptorCmd{}))
+	require.True(t, localCmd(&convertCmd{}))
+	require.True(t, localCmd(&fdsCmd{}))
+	require.True(t, localCmd(&cacheClearCmd{}))
+	require.False(t, localCmd(&servicesCmd{}))
+	require.False(t, localCmd(&watchCmd{}))
+}
+
+func TestRunTargets(t *testing.T) {
+	server2, addr2 := startServer(t, registerEcho(t, 2))
+	defer server2.Stop()
+	server3, addr3 := startServer(t, registerEcho(t, 3))
+	defer server3.Stop()
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	missing := lis.Addr().String()
+	require.NoError(t, lis.Close())
+
+	b := &bytes.Buffer{}
+	g := globals{Plaintext: true, Format: "json", Field: "list_services_response.service.name", out: b}
+	targets := []string{addr3, missing, addr2}
+	err = runTargets(&servicesCmd{}, g, targets, 2)
+	require.EqualError(t, err, "1 of 3 targets failed")
+
+	got := strings.Split(b.String(), "\n")
+	require.Equal(t, "==> "+addr3+" <==", got[0])
+	require.Equal(t, "echo3.Echo", got[1])
+	require.Equal(t, "==> "+missing+" <==", got[3])
+	require.True(t, strings.HasPrefix(got[4], "error: cannot setup reflection stream"))
+	require.Equal(t, "==> "+addr2+" <==", got[5])
+	require.Equal(t, "echo2.Echo", got[6])
+}
+
+func TestReadTargets(t *testing.T) {
+	fname := path.Join(t.TempDir(), "targets")
+	content := "# replicas\nlocalhost:9090\n\n  localhost:9091 \n"
+	require.NoError(t, os.WriteFile(fname, []byte(content), 0600))
+	got, err := readTargets(fname)
+	require.NoError(t, err)
+	require.Equal(t, []string{"localhost:9090", "localhost:9091"}, got)
+
+	_, err = readTargets(path.Join(t.TempDir(), "MISSING"))
+	require.Error(t, err)
+}
+
+func TestDescriptorCache(t *testing.T) {
+	server, addr := startServer(t, registerEcho(t, 3))
+	b := &bytes.Buffer{}
+	g := globals{
+		Address:   addr,
+		Plaintext: true,
+		Cache:     true,
+		CacheDir:  t.TempDir(),
+		CacheTTL:  time.Hour,
+		out:       b,
+	}
+	r := newTypeRegistry(withCache(&serverFetcher{g: g}, g))
+	_, err := r.findDescriptor("echo3.Echo")
+	require.NoError(t, err)
+	server.Stop()
+
+	fdps, err := withCache(&serverFetcher{g: g}, g).fileContainingSymbol("echo3.Echo")
+	require.NoError(t, err)
+	require.Len(t, fdps, 1)
+	r = newTypeRegistry(withCache(&serverFetcher{g: g}, g))
+	require.NoError(t, r.addFiles(fdps))
+	d, err := r.files.FindDescriptorByName("echo3.Echo")
+	require.NoError(t, err)
+	require.Equal(t, "echo3/echo3.proto", d.ParentFile().Path())
+	_, err = r.findDescriptor("echo3.MISSING")
+	require.Error(t, err)
+
+	err = (&cacheLsCmd{}).Run(g)
+	require.NoError(t, err)
+	require.Contains(t, b.String(), addr+" echo3/echo3.proto")
+	require.Contains(t, b.String(), "fresh")
+
+	g.CacheTTL = time.Nanosecond
+	_, err = withCache(&serverFetcher{g: g}, g).fileContainingSymbol("echo3.Echo")
+	require.Error(t, err)
+
+	b.Reset()
+	err = (&cacheClearCmd{Target: addr}).Run(g)
+	require.NoError(t, err)
+	g.NoHeaders = true
+	err = (&cacheLsCmd{}).Run(g)
+	require.NoError(t, err)
+	require.Empty(t, b.String())
+}
+
+func TestCacheDotTargets(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := path.Join(dir, "cache")
+	require.NoError(t, os.MkdirAll(path.Join(cacheDir, "localhost:9090"), 0o700))
+	g := globals{Cache: true, CacheDir: cacheDir, out: &bytes.Buffer{}}
+
+	for _, target := range []string{".", ".."} {
+		err := (&cacheClearCmd{Target: target}).Run(g)
+		require.NoError(t, err)
+		require.DirExists(t, path.Join(cacheDir, "localhost:9090"))
+
+		g.Address = target
+		f, ok := withCache(&serverFetcher{g: g}, g).(*cachingFetcher)
+		require.True(t, ok)
+		require.Equal(t, path.Join(cacheDir, strings.Repeat("%2E", len(target))), f.cache.dir)
+		require.Equal(t, target, unescape(escape(target)))
+	}
+	g.Address = ""
+	_, ok := withCache(&serverFetcher{g: g}, g).(*cachingFetcher)
+	require.False(t, ok)
+}
+
+func TestWatchCmd(t *testing.T) {
+	server, addr := startServer(t, registerEcho(t, 3))
+	b := &bytes.Buffer{}
+	g := globals{Address: addr, Plaintext: true, out: b}
+	s, err := fetchSchema(g)
+	require.NoError(t, err)
+	require.Equal(t, []string{"echo3.Echo", "grpc.reflection.v1alpha.ServerReflection"}, s.services)
+	require.Contains(t, s.files, "echo3/echo3.proto")
+	require.Contains(t, s.files, "google/api/http.proto")
+
+	err = (&watchCmd{Count: 2, Interval: time.Millisecond}).Run(g)
+	require.NoError(t, err)
+	server.Stop()
+	err = (&watchCmd{Count: 1}).Run(g)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	require.Len(t, lines, 2)
+	event := watchEvent{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	require.Equal(t, "initial", event.Event)
+	require.Equal(t, s.fingerprint(), event.Fingerprint)
+	event = watchEvent{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	require.Equal(t, "error", event.Event)
+	require.NotEmpty(t, event.Error)
+}
+
+func TestWatchDiff(t *testing.T) {
+	prev := &schema{
+		services: []string{"a.A", "b.B"},
+		files:    map[string][]byte{"a.proto": {1}, "b.proto": {2}},
+	}
+	cur := &schema{
+		services: []string{"a.A", "c.C"},
+		files:    map[string][]byte{"a.proto": {3}, "c.proto": {4}},
+	}
+	event := diffSchemas(prev, cur)
+	require.Equal(t, "changed", event.Event)
+	require.NotEqual(t, event.Fingerprint, event.PreviousFingerprint)
+	require.Equal(t, []string{"c.C"}, event.ServicesAdded)
+	require.Equal(t, []string{"b.B"}, event.ServicesRemoved)
+	require.Equal(t, []string{"c.proto"}, event.FilesAdded)
+	require.Equal(t, []string{"b.proto"}, event.FilesRemoved)
+	require.Equal(t, []string{"a.proto"}, event.FilesChanged)
+
+	fname := path.Join(t.TempDir(), "event")
+	w := &watchCmd{Exec: "cat > " + fname + " && echo $REFLECT_FINGERPRINT >> " + fname}
+	b := &bytes.Buffer{}
+	err := w.emit(event, globals{out: b})
+	require.NoError(t, err)
+	got, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	require.Equal(t, b.String()+event.Fingerprint+"\n", string(got))
+
+	b.Reset()
+	w = &watchCmd{Exec: "echo hook; exit 1"}
+	err = w.emit(event, globals{out: b})
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(b.String(), "}\nhook\n"))
+}
+
+func TestHealthCmd(t *testing.T) {
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("echo3.Echo", healthpb.HealthCheckResponse_NOT_SERVING)
+	server, addr := startServer(t, func(s *grpc.Server) { healthpb.RegisterHealthServer(s, healthServer) })
+	defer server.Stop()
+
+	b := &bytes.Buffer{}
+	g := globals{Address: addr, Plaintext: true, Format: "json", out: b}
+	err := (&healthCmd{}).Run(g)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"status": "SERVING"}`, b.String())
+
+	b.Reset()
+	err = (&healthCmd{Service: "echo3.Echo"}).Run(g)
+	require.Equal(t, statusError{status: healthpb.HealthCheckResponse_NOT_SERVING}, err)
+	require.Equal(t, 2, err.(exitCoder).ExitCode())
+	require.JSONEq(t, `{"status": "NOT_SERVING"}`, b.String())
+
+	b.Reset()
+	g.Format = "table"
+	err = (&healthCmd{Service: "MISSING"}).Run(g)
+	require.Equal(t, 3, err.(exitCoder).ExitCode())
+	require.Equal(t, "STATUS\nSERVICE_UNKNOWN\n", b.String())
+
+	// Every status is read from the pipe before the next one is set.
+	pr, pw := io.Pipe()
+	lines := bufio.NewScanner(pr)
+	g.Format = "text"
+	g.out = pw
+	done := make(chan error)
+	go func() { done <- (&healthCmd{Service: "echo3.Echo", Watch: true}).Run(g) }()
+	require.True(t, lines.Scan())
+	require.Equal(t, "status:NOT_SERVING", lines.Text())
+	healthServer.SetServingStatus("echo3.Echo", healthpb.HealthCheckResponse_SERVING)
+	require.True(t, lines.Scan())
+	require.Equal(t, "status:SERVING", lines.Text())
+	server.Stop()
+	require.Error(t, <-done)
+}
+
+func TestChannelzCmd(t *testing.T) {
+	server, addr := startServerOn(t, "127.0.0.1:0", func(s *grpc.Server) { channelz.RegisterChannelzServiceToServer(s) })
+	defer server.Stop()
+
+	b := &bytes.Buffer{}
+	g := globals{Address: addr, Plaintext: true, Format: "json", out: b}
+	err := (&channelzServersCmd{}).Run(g)
+	require.NoError(t, err)
+	servers := &channelzpb.GetServersResponse{}
+	require.NoError(t, protojson.Unmarshal(b.Bytes(), servers))
+	require.True(t, servers.GetEnd())
+	require.NotEmpty(t, servers.GetServer())
+	var serverID int64
+	for _, s := range servers.GetServer() {
+		if len(s.GetListenSocket()) != 0 {
+			serverID = s.GetRef().GetServerId()
+		}
+	}
+	require.NotZero(t, serverID)
+
+	b.Reset()
+	g.Format = "table"
+	err = (&channelzChannelsCmd{}).Run(g)
+	require.NoError(t, err)
+	require.Contains(t, b.String(), addr+" READY")
+
+	b.Reset()
+	err = (&channelzSocketsCmd{ServerID: serverID}).Run(g)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[1], " "+addr+" ")
+
+	b.Reset()
+	g.Format = "text"
+	err = (&channelzSocketsCmd{ServerID: serverID}).Run(g)
+	require.NoError(t, err)
+	require.Contains(t, b.String(), "socket:")
+
+	b.Reset()
+	err = (&channelzSocketsCmd{ServerID: -1}).Run(g)
+	require.NoError(t, err)
+	require.Empty(t, b.String())
+}
+
+func TestRunBackends(t *testing.T) {
+	server1, addr1 := startServerOn(t, "127.0.0.1:0", registerEcho(t, 2))
+	defer server1.Stop()
+	_, port, err := net.SplitHostPort(addr1)
+	require.NoError(t, err)
+	server2, _ := startServerOn(t, "127.0.0.2:"+port, registerEcho(t, 3))
+	defer server2.Stop()
+	server4, _ := startServerOn(t, "127.0.0.4:"+port, registerEcho(t, 2))
+	defer server4.Stop()
+	defer func(f func(context.Context, string) ([]string, error)) { lookupHost = f }(lookupHost)
+	lookupHost = func(_ context.Context, host string) ([]string, error) {
+		require.Equal(t, "echo.test", host)
+		return []string{"127.0.0.4", "127.0.0.3", "127.0.0.2", "127.0.0.1"}, nil
+	}
+
+	b := &bytes.Buffer{}
+	g := globals{Address: "dns:///echo.test:" + port, Plaintext: true, Format: "json", Field: "list_services_response.service.name", out: b}
+	err = runBackends(&servicesCmd{}, g, 2)
+	require.EqualError(t, err, "1 of 4 backends failed")
+
+	got := strings.Split(b.String(), "\n")
+	require.Regexp(t, "^==> schema [0-9a-f]{12}: 127.0.0.1:"+port+", 127.0.0.4:"+port+" <==$", got[0])
+	require.Equal(t, "echo2.Echo", got[1])
+	require.Regexp(t, "^==> schema [0-9a-f]{12}: 127.0.0.2:"+port+" <==$", got[3])
+	require.Equal(t, "echo3.Echo", got[4])
+	require.Equal(t, "==> 127.0.0.3:"+port+" <==", got[6])
+	require.True(t, strings.HasPrefix(got[7], "error: "))
+
+	lookupHost = func(context.Context, string) ([]string, error) {
+		return []string{"127.0.0.2", "127.0.0.1"}, nil
+	}
+	err = runBackends(&servicesCmd{}, g, 2)
+	require.EqualError(t, err, "2 different schemas across 2 backends")
+}
+
+func TestSplitDNSTarget(t *testing.T) {
+	tests := map[string][]string{
+		"dns:///example.com:8080": {"example.com", "8080"},
+		"dns:example.com:8080":    {"example.com", "8080"},
+		"example.com":             {"example.com", "443"},
+		"[::1]:9090":              {"::1", "9090"},
+	}
+	for target, want := range tests {
+		host, port, err := splitDNSTarget(target)
+		require.NoError(t, err)
+		require.Equal(t, want, []string{host, port}, target)
+	}
+	_, _, err := splitDNSTarget("dns://8.8.8.8/example.com")
+	require.Error(t, err)
+	_, _, err = splitDNSTarget("")
+	require.Error(t, err)
+}
+
+func TestFaults(t *testing.T) {
+	tests := map[string]struct {
+		cfg  faults.Config
+		code codes.Code
+		want string
+	}{
+		"unavailable":  {cfg: faults.Config{Code: codes.Unavailable}, code: codes.Unavailable, want: "cannot receive reflection response"},
+		"other method": {cfg: faults.Config{Methods: "Health", Code: codes.Unavailable}},
+		"drop":         {cfg: faults.Config{DropAfter: 1}, code: codes.Aborted, want: "cannot receive reflection response"},
+		"malformed":    {cfg: faults.Config{MalformedFiles: true}, want: "cannot decode file descriptor"},
+		"out of order": {cfg: faults.Config{OutOfOrder: true}, want: "reflection response out of order"},
+		"latency":      {cfg: faults.Config{Latency: 20 * time.Millisecond}},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			server, addr := startServer(t, registerEcho(t, 3), faultOptions(t, tc.cfg)...)
+			defer server.Stop()
+			start := time.Now()
+			s, err := fetchSchema(globals{Address: addr, Plaintext: true})
+			if tc.want == "" {
+				require.NoError(t, err)
+				require.Contains(t, s.services, "echo3.Echo")
+				require.GreaterOrEqual(t, int64(time.Since(start)), int64(2*tc.cfg.Latency))
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.want)
+			if tc.code != codes.OK {
+				require.Equal(t, tc.code, status.Code(errors.Cause(err)))
+			}
+		})
+	}
+}
+
+func TestFindDescriptorFetchErr(t *testing.T) {
+	server, addr := startServer(t, registerEcho(t, 3), faultOptions(t, faults.Config{OutOfOrder: true})...)
+	defer server.Stop()
+	g := globals{Address: addr, Plaintext: true}
+	r := newTypeRegistry(&serverFetcher{g: g})
+	_, err := r.findDescriptor("grpc.reflection.v1alpha.ServerReflection")
+	require.NoError(t, err)
+	_, err = r.findDescriptor("echo3.Echo")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "reflection response out of order")
+}
+
+func TestTypeRegistryClose(t *testing.T) {
+	server, addr := startServer(t, registerEcho(t, 3))
+	defer server.Stop()
+	f := &serverFetcher{g: globals{Address: addr, Plaintext: true}}
+	r := newTypeRegistry(f)
+	_, err := r.findDescriptor("echo3.Echo")
+	require.NoError(t, err)
+	conn := f.client.Conn()
+	require.NoError(t, r.Close())
+	require.Nil(t, f.client)
+	require.Equal(t, connectivity.Shutdown, conn.GetState())
+	require.NoError(t, r.Close())
+	require.NoError(t, newTypeRegistry(nil).Close())
+}
+
+func TestLibrarySchema(t *testing.T) {
+	server, addr := startServer(t, registerLibrary(t))
+	defer server.Stop()
+	b := &bytes.Buffer{}
+	g := globals{Address: addr, Plaintext: true, Format: "json", out: b}
+
+	f := &serverFetcher{g: g}
+	defer f.Close()
+	fdps, err := f.fileContainingSymbol("library.Library")
+	require.NoError(t, err)
+	var names []string
+	for _, fdp := range fdps {
+		names = append(names, fdp.GetName())
+	}
+	require.Equal(t, "library/library.proto", names[0])
+	require.ElementsMatch(t, []string{
+		"library/library.proto",
+		"library/options/options.proto",
+		"library/types/public.proto",
+		"library/types/types.proto",
+		"google/protobuf/descriptor.proto",
+	}, names)
+
+	// library.proto does not import types.proto, Book is only reachable
+	// through the public import of public.proto.
+	r := newTypeRegistry(&serverFetcher{g: g})
+	d, err := r.findDescriptor("library.Library")
+	require.NoError(t, err)
+	imports := d.ParentFile().Imports()
+	require.Equal(t, 2, imports.Len())
+	require.Equal(t, "library/options/options.proto", imports.Get(0).Path())
+	require.Equal(t, "library/types/public.proto", imports.Get(1).Path())
+	public := imports.Get(1).Imports().Get(0)
+	require.True(t, public.IsPublic)
+	require.Equal(t, "library/types/types.proto", public.Path())
+	output := d.(protoreflect.ServiceDescriptor).Methods().ByName("GetBook").Output()
+	require.Equal(t, protoreflect.FullName("library.types.Book"), output.FullName())
+	require.Equal(t, "library/types/types.proto", output.ParentFile().Path())
+
+	mt, err := r.FindMessageByName("library.types.Book")
+	require.NoError(t, err)
+	chapter := mt.Descriptor().Fields().ByName("chapter")
+	require.Equal(t, protoreflect.GroupKind, chapter.Kind())
+	require.Equal(t, protoreflect.FullName("library.types.Book.Chapter"), chapter.Message().FullName())
+	xt, err := r.FindExtensionByNumber("library.types.Book", 100)
+	require.NoError(t, err)
+	require.Equal(t, protoreflect.FullName("library.isbn"), xt.TypeDescriptor().FullName())
+
+	want := `{
+		"name": "The Go Programming Language",
+		"author": {"name": "Alan Donovan", "email": "alan@example.com", "address": {"city": "New York", "country": "US"}},
+		"chapter": [{"title": "Tutorial", "pages": 34}, {"title": "Program Structure", "pages": 26}],
+		"format": "PAPERBACK",
+		"[library.isbn]": "978-0134190440"
+	}`
+	m := mt.New().Interface()
+	require.NoError(t, protojson.UnmarshalOptions{Resolver: r}.Unmarshal([]byte(want), m))
+	book, err := proto.Marshal(m)
+	require.NoError(t, err)
+	m = mt.New().Interface()
+	require.NoError(t, proto.UnmarshalOptions{Resolver: r}.Unmarshal(book, m))
+	j, err := protojson.MarshalOptions{Resolver: r}.Marshal(m)
+	require.NoError(t, err)
+	require.JSONEq(t, want, string(j))
+
+	err = (&extensionsCmd{Type: "library.types.Book", Resolve: true}).Run(g)
+	require.NoError(t, err)
+	require.Equal(t, "NUMBER NAME         TYPE   FILE\n100    library.isbn string library/library.proto\n", b.String())
+
+	b.Reset()
+	err = (&extensionsCmd{Type: "google.protobuf.FieldOptions", Resolve: true}).Run(g)
+	require.NoError(t, err)
+	require.Contains(t, b.String(), "50002  library.options.sensitive     bool              library/options/options.proto\n")
+
+	b.Reset()
+	err = (&extensionCmd{Type: "google.protobuf.MethodOptions", Number: 50001}).Run(g)
+	require.NoError(t, err)
+	resp := &rpb.ServerReflectionResponse{}
+	require.NoError(t, protojson.Unmarshal(b.Bytes(), resp))
+	fdp := &dpb.FileDescriptorProto{}
+	require.NoError(t, proto.Unmarshal(resp.GetFileDescriptorResponse().GetFileDescriptorProto()[0], fdp))
+	require.Equal(t, "library/options/options.proto", fdp.GetName())
+
+	schema, err := fetchSchema(g)
+	require.NoError(t, err)
+	require.Contains(t, schema.services, "library.Library")
+	require.Contains(t, schema.files, "library/types/types.proto")
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	caFile := path.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0600))
+	newCert := func(serial int64, usage x509.ExtKeyUsage) tls.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	}
+	clientCert := newCert(3, x509.ExtKeyUsageClientAuth)
+	certFile, keyFile := path.Join(dir, "client.pem"), path.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientCert.Certificate[0]}), 0600))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(clientCert.PrivateKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{newCert(2, x509.ExtKeyUsageServerAuth)},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	server, addr := startServerOn(t, "127.0.0.1:0", registerEcho(t, 3), grpc.Creds(creds))
+	defer server.Stop()
+
+	b := &bytes.Buffer{}
+	g := globals{Address: addr, CACert: caFile, Cert: certFile, Key: keyFile, Format: "json", Field: "list_services_response.service.name", out: b}
+	err = (&servicesCmd{}).Run(g)
+	require.NoError(t, err)
+	require.Equal(t, "echo3.Echo\ngrpc.reflection.v1alpha.ServerReflection\n", b.String())
+
+	g.Cert, g.Key = "", ""
+	err = (&servicesCmd{}).Run(g)
+	require.Error(t, err)
+
+	g.CACert = ""
+	g.Cert = certFile
+	err = (&servicesCmd{}).Run(g)
+	require.EqualError(t, err, "--cert and --key must be given together")
+}
+
 func TestReflectSuite(t *testing.T) {
 	suite.Run(t, &ReflectSuite{format: "json", pbVersion: 3})
 	suite.Run(t, &ReflectSuite{format: "base64", pbVersion: 3})
@@ -196,6 +955,8 @@ func TestReflectSuite(t *testing.T) {
 	suite.Run(t, &ReflectSuite{format: "json", pbVersion: 2})
 	suite.Run(t, &ReflectSuite{format: "base64", pbVersion: 2})
 	suite.Run(t, &ReflectSuite{format: "bin", pbVersion: 2})
+	suite.Run(t, &ReflectSuite{format: "yaml", pbVersion: 3})
+	suite.Run(t, &ReflectSuite{format: "yaml", pbVersion: 2})
 }
 
 type ReflectSuite struct {
@@ -209,35 +970,89 @@ type ReflectSuite struct {
 }
 
 func (s *ReflectSuite) SetupSuite() {
-	t := s.T()
-	s.server = grpc.NewServer()
-	switch s.pbVersion {
-	case 2:
-		echo2Server := &echo2.Server{}
-		echo2.RegisterEchoServer(s.server, echo2Server)
-	case 3:
-		echo3Server := &echo3.Server{}
-		echo3.RegisterEchoServer(s.server, echo3Server)
-	default:
-		require.Fail(t, "unknown proto version")
+	var addr string
+	s.server, addr = startServer(s.T(), registerEcho(s.T(), s.pbVersion))
+	s.globals = globals{
+		Address:     addr,
+		Plaintext:   true,
+		Format:      s.format,
+		hostAddress: "localhost:0",
 	}
-	reflection.Register(s.server)
-	lis, err := net.Listen("tcp", "localhost:0")
+	s.subDir = fmt.Sprintf("proto%d-%s", s.pbVersion, s.format)
+}
+
+// startServer starts a gRPC server with the services registered by
+// register and, unless register adds its own, the reflection service. It
+// returns the server with its address.
+func startServer(t *testing.T, register func(*grpc.Server), opts ...grpc.ServerOption) (*grpc.Server, string) {
+	t.Helper()
+	return startServerOn(t, "localhost:0", register, opts...)
+}
+
+// startServerOn is startServer listening on addr. The returned address
+// has the host of addr and the port listened on.
+func startServerOn(t *testing.T, addr string, register func(*grpc.Server), opts ...grpc.ServerOption) (*grpc.Server, string) {
+	t.Helper()
+	server := grpc.NewServer(opts...)
+	register(server)
+	if _, ok := server.GetServiceInfo()["grpc.reflection.v1alpha.ServerReflection"]; !ok {
+		reflection.Register(server)
+	}
+	lis, err := net.Listen("tcp", addr)
+	require.NoError(t, err)
+	host, _, err := net.SplitHostPort(addr)
 	require.NoError(t, err)
 	port := lis.Addr().(*net.TCPAddr).Port
 	serve := func() {
-		if err := s.server.Serve(lis); err != nil {
+		if err := server.Serve(lis); err != nil {
 			panic(err)
 		}
 	}
-	s.globals = globals{
-		Address:     fmt.Sprintf("localhost:%d", port),
-		Plaintext:   true,
-		Format:      s.format,
-		hostAddress: "localhost:0",
-	}
-	s.subDir = fmt.Sprintf("proto%d-%s", s.pbVersion, s.format)
 	go serve()
+	return server, fmt.Sprintf("%s:%d", host, port)
+}
+
+// registerLibrary returns a register func for reflection of the library
+// schema in testdata/library.pb. The library package is not linked into
+// the tests, so its extensions are not part of the echo server responses.
+func registerLibrary(t *testing.T) func(*grpc.Server) {
+	t.Helper()
+	b, err := os.ReadFile("testdata/library.pb")
+	require.NoError(t, err)
+	fds := &dpb.FileDescriptorSet{}
+	require.NoError(t, proto.Unmarshal(b, fds))
+	files, err := protodesc.NewFiles(fds)
+	require.NoError(t, err)
+	types := &protoregistry.Types{}
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		require.NoError(t, dynamictypes.Register(types, fd))
+		return true
+	})
+	return func(s *grpc.Server) {
+		reflectserver.Register(s, files, types, []string{"library.Library"})
+	}
+}
+
+// registerEcho returns a register func for the echo service of the given
+// proto version.
+func registerEcho(t *testing.T, pbVersion int) func(*grpc.Server) {
+	t.Helper()
+	switch pbVersion {
+	case 2:
+		return func(s *grpc.Server) { echo2.RegisterEchoServer(s, &echo2.Server{}) }
+	case 3:
+		return func(s *grpc.Server) { echo3.RegisterEchoServer(s, &echo3.Server{}) }
+	}
+	require.Fail(t, "unknown proto version")
+	return nil
+}
+
+// faultOptions returns the server options injecting the faults of cfg.
+func faultOptions(t *testing.T, cfg faults.Config) []grpc.ServerOption {
+	t.Helper()
+	injector, err := faults.New(cfg)
+	require.NoError(t, err)
+	return injector.ServerOptions()
 }
 
 func (s *ReflectSuite) TearDownSuite() {
@@ -352,7 +1167,8 @@ func (s *ReflectSuite) TestExtensionsCmd() {
 	err := cmd.Run(s.globals)
 
 	require.NoError(t, err)
-	// undetermined order of extension numbers in response call don't allow for golden comparison
+	// undetermined order of extension numbers in response call don't allow for golden comparison,
+	// see TestExtensionsCmdStable.
 	// requireContentEq(t, f.want, f.got, s.format)
 	wantResp := reflectionResponse(t, f.want, s.format)
 	gotResp := reflectionResponse(t, f.got, s.format)
@@ -364,6 +1180,128 @@ func (s *ReflectSuite) TestExtensionsCmd() {
 	require.ElementsMatch(t, want, got)
 }
 
+func (s *ReflectSuite) TestExtensionsCmdStable() {
+	t := s.T()
+	f := files(t, s.format, s.subDir)
+	s.globals.out = f.out
+	s.globals.Stable = true
+	defer func() { s.globals.Stable = false }()
+
+	cmd := extensionsCmd{Type: "google.protobuf.MethodOptions"}
+	err := cmd.Run(s.globals)
+
+	require.NoError(t, err)
+	want, err := os.ReadFile(f.want)
+	require.NoError(t, err)
+	got, err := os.ReadFile(f.got)
+	require.NoError(t, err)
+	require.Equal(t, string(want), string(got))
+	require.Empty(t, reflectionResponse(t, f.got, s.format).GetValidHost())
+}
+
+func (s *ReflectSuite) TestServicesCmdSelect() {
+	t := s.T()
+	b := &bytes.Buffer{}
+	g := s.globals
+	g.out = b
+	want := fmt.Sprintf("echo%d.Echo\ngrpc.reflection.v1alpha.ServerReflection\n", s.pbVersion)
+
+	g.Template = `{{range .ListServicesResponse.Service}}{{.Name}}{{"\n"}}{{end}}`
+	err := (&servicesCmd{}).Run(g)
+	require.NoError(t, err)
+	require.Equal(t, want, b.String())
+
+	b.Reset()
+	g.Template = ""
+	g.Field = "list_services_response.service.name"
+	err = (&servicesCmd{}).Run(g)
+	require.NoError(t, err)
+	require.Equal(t, want, b.String())
+
+	b.Reset()
+	g.Field = "listServicesResponse.service"
+	err = (&servicesCmd{}).Run(g)
+	require.NoError(t, err)
+	require.NotEmpty(t, b.String())
+
+	g.Field = "error_response.MISSING"
+	err = (&servicesCmd{}).Run(g)
+	require.Error(t, err)
+
+	g.Field = "valid_host.MISSING"
+	err = (&servicesCmd{}).Run(g)
+	require.Error(t, err)
+
+	g.Field = ""
+	g.Template = "{{.ValidHost"
+	err = (&servicesCmd{}).Run(g)
+	require.Error(t, err)
+}
+
+func (s *ReflectSuite) TestServicesCmdTable() {
+	t := s.T()
+	b := &bytes.Buffer{}
+	g := s.globals
+	g.out = b
+	g.Format = "table"
+
+	err := (&servicesCmd{}).Run(g)
+	require.NoError(t, err)
+	want := fmt.Sprintf(`SERVICE                                  METHODS FILE
+echo%[1]d.Echo                               6       echo%[1]d/echo%[1]d.proto
+grpc.reflection.v1alpha.ServerReflection 1       reflection/grpc_reflection_v1alpha/reflection.proto
+`, s.pbVersion)
+	require.Equal(t, want, b.String())
+
+	b.Reset()
+	g.Wide = true
+	g.NoHeaders = true
+	err = (&servicesCmd{}).Run(g)
+	require.NoError(t, err)
+	want = fmt.Sprintf(`echo%[1]d.Echo                               6 echo%[1]d/echo%[1]d.proto                                   echo%[1]d                   proto%[1]d
+grpc.reflection.v1alpha.ServerReflection 1 reflection/grpc_reflection_v1alpha/reflection.proto grpc.reflection.v1alpha proto3
+`, s.pbVersion)
+	require.Equal(t, want, b.String())
+
+	b.Reset()
+	g.Wide = false
+	g.NoHeaders = false
+	err = (&symbolCmd{Symbol: "MISSING"}).Run(g)
+	require.NoError(t, err)
+	require.Equal(t, "CODE MESSAGE\n5    unknown symbol: MISSING\n", b.String())
+}
+
+func (s *ReflectSuite) TestExtensionsCmdResolve() {
+	t := s.T()
+	b := &bytes.Buffer{}
+	s.globals.out = b
+
+	cmd := extensionsCmd{Type: "google.protobuf.MethodOptions", Resolve: true}
+	err := cmd.Run(s.globals)
+
+	require.NoError(t, err)
+	want := `NUMBER   NAME                        TYPE     FILE
+1051     google.api.method_signature string   google/api/client.proto
+72295728 google.api.http             HttpRule google/api/annotations.proto
+`
+	require.Equal(t, want, b.String())
+
+	b.Reset()
+	g := s.globals
+	g.NoHeaders = true
+	g.Wide = true
+	err = cmd.Run(g)
+	require.NoError(t, err)
+	want = `1051     google.api.method_signature string   google/api/client.proto      repeated google.api
+72295728 google.api.http             HttpRule google/api/annotations.proto optional google.api
+`
+	require.Equal(t, want, b.String())
+
+	cmd = extensionsCmd{Type: "MISSING", Resolve: true}
+	err = cmd.Run(s.globals)
+	require.Error(t, err)
+}
+
 func (s *ReflectSuite) TestExtensionsCmdErr() {
 	t := s.T()
 	f := files(t, s.format, s.subDir)
@@ -377,6 +1315,26 @@ func (s *ReflectSuite) TestExtensionsCmdErr() {
 	requireResponseErr(t, f.got, s.format)
 }
 
+func (s *ReflectSuite) TestRawCmd() {
+	t := s.T()
+	f := files(t, s.format, s.subDir)
+	s.globals.out = f.out
+
+	filename := fmt.Sprintf("echo%d/echo%d.proto", s.pbVersion, s.pbVersion)
+	req := fmt.Sprintf(`host: "custom" file_by_filename: %q`, filename)
+	cmd := rawCmd{Request: req, InFormat: "text"}
+	err := cmd.Run(s.globals)
+
+	require.NoError(t, err)
+	resp := reflectionResponse(t, f.got, s.format)
+	require.Equal(t, "custom", resp.GetValidHost())
+	require.NotEmpty(t, resp.GetFileDescriptorResponse().GetFileDescriptorProto())
+
+	cmd = rawCmd{Request: "{}", InFormat: "json"}
+	err = cmd.Run(s.globals)
+	require.Error(t, err)
+}
+
 func requireResponseErr(t *testing.T, fname, format string) {
 	t.Helper()
 	resp := reflectionResponse(t, fname, format)
@@ -417,6 +1375,12 @@ func reflectionResponse(t *testing.T, fname string, format string) *rpb.ServerRe
 		err = proto.Unmarshal(b, &r)
 	case "bin":
 		err = proto.Unmarshal(b, &r)
+	case "yaml":
+		var v interface{}
+		require.NoError(t, yaml.Unmarshal(b, &v))
+		b, err = json.Marshal(v)
+		require.NoError(t, err)
+		err = protojson.Unmarshal(b, &r)
 	default:
 		err = fmt.Errorf("unknown format type")
 	}
@@ -446,3 +1410,53 @@ func files(t *testing.T, format, subDir string) outputFiles {
 	require.NoError(t, err)
 	return f
 }
+
+func (s *ReflectSuite) TestAnyResolverServer() {
+	t := s.T()
+	b := &bytes.Buffer{}
+	g := s.globals
+	g.Format = "json"
+	g.out = b
+	r := newTypeRegistry(&serverFetcher{g: g})
+	g.resolver = r
+
+	// echo types are linked into the test binary, so fetch explicitly
+	// to check that the server provides them.
+	name := fmt.Sprintf("echo%d.HelloRequest", s.pbVersion)
+	require.NoError(t, r.fetchSymbol(name))
+	mt, err := r.types.FindMessageByName(protoreflect.FullName(name))
+	require.NoError(t, err)
+	require.IsType(t, &dynamicpb.Message{}, mt.New().Interface())
+
+	url := "type.googleapis.com/" + name
+	value, err := proto.Marshal(&echo3.HelloRequest{Message: "hello"})
+	require.NoError(t, err)
+	err = printProto(&anypb.Any{TypeUrl: url, Value: value}, g)
+	require.NoError(t, err)
+	want := fmt.Sprintf(`{"@type": %q, "message": "hello"}`, url)
+	require.JSONEq(t, want, b.String())
+
+	_, err = r.FindMessageByName("MISSING")
+	require.Error(t, err)
+}
+
+func TestProtosetResolver(t *testing.T) {
+	fds := &dpb.FileDescriptorSet{File: []*dpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(echo3.File_echo3_echo3_proto),
+	}}
+	b, err := proto.Marshal(fds)
+	require.NoError(t, err)
+	fname := path.Join(t.TempDir(), "echo3.pb")
+	require.NoError(t, os.WriteFile(fname, b, 0600))
+
+	r, err := newResolver(globals{AnyResolver: "protoset", Protoset: fname})
+	require.NoError(t, err)
+	tr, ok := r.(*typeRegistry)
+	require.True(t, ok)
+	mt, err := tr.types.FindMessageByName("echo3.Details")
+	require.NoError(t, err)
+	require.IsType(t, &dynamicpb.Message{}, mt.New().Interface())
+
+	_, err = newResolver(globals{AnyResolver: "protoset"})
+	require.Error(t, err)
+}
@@ -0,0 +1,46 @@
+// Package dynamictypes registers dynamicpb types for the messages and
+// extensions of file descriptors that are not linked into the binary,
+// e.g. those fetched by reflection or read from a protoset.
+package dynamictypes
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Register registers all messages and extensions of fd with types,
+// including nested ones.
+func Register(types *protoregistry.Types, fd protoreflect.FileDescriptor) error {
+	if err := registerMessages(types, fd.Messages()); err != nil {
+		return err
+	}
+	return registerExtensions(types, fd.Extensions())
+}
+
+func registerMessages(types *protoregistry.Types, mds protoreflect.MessageDescriptors) error {
+	for i := 0; i < mds.Len(); i++ {
+		md := mds.Get(i)
+		if err := types.RegisterMessage(dynamicpb.NewMessageType(md)); err != nil {
+			return errors.Wrapf(err, "cannot register message %s", md.FullName())
+		}
+		if err := registerMessages(types, md.Messages()); err != nil {
+			return err
+		}
+		if err := registerExtensions(types, md.Extensions()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func registerExtensions(types *protoregistry.Types, xds protoreflect.ExtensionDescriptors) error {
+	for i := 0; i < xds.Len(); i++ {
+		xd := xds.Get(i)
+		if err := types.RegisterExtension(dynamicpb.NewExtensionType(xd)); err != nil {
+			return errors.Wrapf(err, "cannot register extension %s", xd.FullName())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package dynamictypes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+const nestedProto = `
+name: "nested.proto"
+package: "nested"
+message_type: {
+  name: "Outer"
+  field: {name: "inner" number: 1 type: TYPE_MESSAGE type_name: ".nested.Outer.Inner" label: LABEL_OPTIONAL}
+  nested_type: {name: "Inner"}
+  extension: {name: "scoped" number: 100 type: TYPE_STRING label: LABEL_OPTIONAL extendee: ".nested.Outer"}
+  extension_range: {start: 100 end: 200}
+}
+extension: {name: "top" number: 101 type: TYPE_INT32 label: LABEL_OPTIONAL extendee: ".nested.Outer"}
+`
+
+func TestRegister(t *testing.T) {
+	fdp := &dpb.FileDescriptorProto{}
+	require.NoError(t, prototext.Unmarshal([]byte(nestedProto), fdp))
+	fd, err := protodesc.NewFile(fdp, nil)
+	require.NoError(t, err)
+
+	types := &protoregistry.Types{}
+	require.NoError(t, Register(types, fd))
+	require.Equal(t, 2, types.NumMessages())
+	require.Equal(t, 2, types.NumExtensions())
+	_, err = types.FindMessageByName("nested.Outer.Inner")
+	require.NoError(t, err)
+	_, err = types.FindExtensionByName("nested.Outer.scoped")
+	require.NoError(t, err)
+	_, err = types.FindExtensionByNumber("nested.Outer", 101)
+	require.NoError(t, err)
+
+	err = Register(types, fd)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot register message nested.Outer")
+}
@@ -6,8 +6,15 @@ package echo3
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // Server implements the server-side of gRPC demo Phone service.
@@ -21,6 +28,39 @@ func (*Server) Hello(_ context.Context, req *HelloRequest) (*HelloResponse, erro
 	return &HelloResponse{RobotResponse: resp}, nil
 }
 
+// HelloDetails returns the details it received for round-trip testing of
+// all field types.
+func (*Server) HelloDetails(_ context.Context, req *Details) (*Details, error) {
+	return req, nil
+}
+
+// HelloError fails with the requested code and BadRequest, ErrorInfo and
+// RetryInfo error details. It succeeds if the requested code is OK.
+func (*Server) HelloError(_ context.Context, req *ErrorRequest) (*HelloResponse, error) {
+	code := codes.Code(req.GetCode())
+	if code == codes.OK {
+		resp := "No error"
+		return &HelloResponse{RobotResponse: resp}, nil
+	}
+	st, err := status.New(code, req.GetMessage()).WithDetails(
+		&errdetails.BadRequest{
+			FieldViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "code", Description: fmt.Sprintf("requested error %s", code)},
+			},
+		},
+		&errdetails.ErrorInfo{
+			Reason:   "REQUESTED",
+			Domain:   "echo3.Echo",
+			Metadata: map[string]string{"code": code.String()},
+		},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(time.Second)},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot add error details")
+	}
+	return nil, st.Err()
+}
+
 // HelloStream streaming RPC handler.
 func (s *Server) HelloStream(req *HelloRequest, stream Echo_HelloStreamServer) error {
 	for i := 0; i < 3; i++ {
@@ -32,3 +72,38 @@ func (s *Server) HelloStream(req *HelloRequest, stream Echo_HelloStreamServer) e
 	}
 	return nil
 }
+
+// HelloCollect client streaming RPC handler.
+func (*Server) HelloCollect(stream Echo_HelloCollectServer) error {
+	var messages []string
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "cannot receive on HelloCollect")
+		}
+		messages = append(messages, req.GetMessage())
+	}
+	resp := fmt.Sprintf("And to you: %s", strings.Join(messages, ", "))
+	err := stream.SendAndClose(&HelloResponse{RobotResponse: resp})
+	return errors.Wrap(err, "cannot send on HelloCollect")
+}
+
+// HelloChat bidirectional streaming RPC handler.
+func (*Server) HelloChat(stream Echo_HelloChatServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "cannot receive on HelloChat")
+		}
+		resp := fmt.Sprintf("And to you: %s", req.GetMessage())
+		if err := stream.Send(&HelloResponse{RobotResponse: resp}); err != nil {
+			return errors.Wrap(err, "cannot send on HelloChat")
+		}
+	}
+}
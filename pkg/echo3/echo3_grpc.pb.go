@@ -23,6 +23,14 @@ type EchoClient interface {
 	Hello(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (*HelloResponse, error)
 	// HelloStream greets repeatedly.
 	HelloStream(ctx context.Context, in *HelloRequest, opts ...grpc.CallOption) (Echo_HelloStreamClient, error)
+	// HelloCollect greets all senders at once.
+	HelloCollect(ctx context.Context, opts ...grpc.CallOption) (Echo_HelloCollectClient, error)
+	// HelloChat greets every sender in turn.
+	HelloChat(ctx context.Context, opts ...grpc.CallOption) (Echo_HelloChatClient, error)
+	// HelloDetails returns the details unchanged.
+	HelloDetails(ctx context.Context, in *Details, opts ...grpc.CallOption) (*Details, error)
+	// HelloError fails with the requested code and error details.
+	HelloError(ctx context.Context, in *ErrorRequest, opts ...grpc.CallOption) (*HelloResponse, error)
 }
 
 type echoClient struct {
@@ -74,6 +82,89 @@ func (x *echoHelloStreamClient) Recv() (*HelloResponse, error) {
 	return m, nil
 }
 
+func (c *echoClient) HelloCollect(ctx context.Context, opts ...grpc.CallOption) (Echo_HelloCollectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Echo_ServiceDesc.Streams[1], "/echo3.Echo/HelloCollect", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &echoHelloCollectClient{stream}
+	return x, nil
+}
+
+type Echo_HelloCollectClient interface {
+	Send(*HelloRequest) error
+	CloseAndRecv() (*HelloResponse, error)
+	grpc.ClientStream
+}
+
+type echoHelloCollectClient struct {
+	grpc.ClientStream
+}
+
+func (x *echoHelloCollectClient) Send(m *HelloRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *echoHelloCollectClient) CloseAndRecv() (*HelloResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(HelloResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *echoClient) HelloChat(ctx context.Context, opts ...grpc.CallOption) (Echo_HelloChatClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Echo_ServiceDesc.Streams[2], "/echo3.Echo/HelloChat", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &echoHelloChatClient{stream}
+	return x, nil
+}
+
+type Echo_HelloChatClient interface {
+	Send(*HelloRequest) error
+	Recv() (*HelloResponse, error)
+	grpc.ClientStream
+}
+
+type echoHelloChatClient struct {
+	grpc.ClientStream
+}
+
+func (x *echoHelloChatClient) Send(m *HelloRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *echoHelloChatClient) Recv() (*HelloResponse, error) {
+	m := new(HelloResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *echoClient) HelloDetails(ctx context.Context, in *Details, opts ...grpc.CallOption) (*Details, error) {
+	out := new(Details)
+	err := c.cc.Invoke(ctx, "/echo3.Echo/HelloDetails", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *echoClient) HelloError(ctx context.Context, in *ErrorRequest, opts ...grpc.CallOption) (*HelloResponse, error) {
+	out := new(HelloResponse)
+	err := c.cc.Invoke(ctx, "/echo3.Echo/HelloError", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EchoServer is the server API for Echo service.
 // All implementations must embed UnimplementedEchoServer
 // for forward compatibility
@@ -82,6 +173,14 @@ type EchoServer interface {
 	Hello(context.Context, *HelloRequest) (*HelloResponse, error)
 	// HelloStream greets repeatedly.
 	HelloStream(*HelloRequest, Echo_HelloStreamServer) error
+	// HelloCollect greets all senders at once.
+	HelloCollect(Echo_HelloCollectServer) error
+	// HelloChat greets every sender in turn.
+	HelloChat(Echo_HelloChatServer) error
+	// HelloDetails returns the details unchanged.
+	HelloDetails(context.Context, *Details) (*Details, error)
+	// HelloError fails with the requested code and error details.
+	HelloError(context.Context, *ErrorRequest) (*HelloResponse, error)
 	mustEmbedUnimplementedEchoServer()
 }
 
@@ -95,6 +194,18 @@ func (UnimplementedEchoServer) Hello(context.Context, *HelloRequest) (*HelloResp
 func (UnimplementedEchoServer) HelloStream(*HelloRequest, Echo_HelloStreamServer) error {
 	return status.Errorf(codes.Unimplemented, "method HelloStream not implemented")
 }
+func (UnimplementedEchoServer) HelloCollect(Echo_HelloCollectServer) error {
+	return status.Errorf(codes.Unimplemented, "method HelloCollect not implemented")
+}
+func (UnimplementedEchoServer) HelloChat(Echo_HelloChatServer) error {
+	return status.Errorf(codes.Unimplemented, "method HelloChat not implemented")
+}
+func (UnimplementedEchoServer) HelloDetails(context.Context, *Details) (*Details, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HelloDetails not implemented")
+}
+func (UnimplementedEchoServer) HelloError(context.Context, *ErrorRequest) (*HelloResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HelloError not implemented")
+}
 func (UnimplementedEchoServer) mustEmbedUnimplementedEchoServer() {}
 
 // UnsafeEchoServer may be embedded to opt out of forward compatibility for this service.
@@ -147,6 +258,94 @@ func (x *echoHelloStreamServer) Send(m *HelloResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Echo_HelloCollect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EchoServer).HelloCollect(&echoHelloCollectServer{stream})
+}
+
+type Echo_HelloCollectServer interface {
+	SendAndClose(*HelloResponse) error
+	Recv() (*HelloRequest, error)
+	grpc.ServerStream
+}
+
+type echoHelloCollectServer struct {
+	grpc.ServerStream
+}
+
+func (x *echoHelloCollectServer) SendAndClose(m *HelloResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *echoHelloCollectServer) Recv() (*HelloRequest, error) {
+	m := new(HelloRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Echo_HelloChat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EchoServer).HelloChat(&echoHelloChatServer{stream})
+}
+
+type Echo_HelloChatServer interface {
+	Send(*HelloResponse) error
+	Recv() (*HelloRequest, error)
+	grpc.ServerStream
+}
+
+type echoHelloChatServer struct {
+	grpc.ServerStream
+}
+
+func (x *echoHelloChatServer) Send(m *HelloResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *echoHelloChatServer) Recv() (*HelloRequest, error) {
+	m := new(HelloRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Echo_HelloDetails_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Details)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EchoServer).HelloDetails(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/echo3.Echo/HelloDetails",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EchoServer).HelloDetails(ctx, req.(*Details))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Echo_HelloError_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ErrorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EchoServer).HelloError(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/echo3.Echo/HelloError",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EchoServer).HelloError(ctx, req.(*ErrorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Echo_ServiceDesc is the grpc.ServiceDesc for Echo service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -158,6 +357,14 @@ var Echo_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Hello",
 			Handler:    _Echo_Hello_Handler,
 		},
+		{
+			MethodName: "HelloDetails",
+			Handler:    _Echo_HelloDetails_Handler,
+		},
+		{
+			MethodName: "HelloError",
+			Handler:    _Echo_HelloError_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -165,6 +372,17 @@ var Echo_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Echo_HelloStream_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "HelloCollect",
+			Handler:       _Echo_HelloCollect_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "HelloChat",
+			Handler:       _Echo_HelloChat_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "echo3/echo3.proto",
 }
@@ -0,0 +1,163 @@
+// Package faults injects deterministic misbehaviour into a gRPC server,
+// such as latency, error codes and broken reflection responses. It is
+// intended for reflect testing only.
+package faults
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// MalformedFile is the invalid serialized FileDescriptorProto that
+// replaces all files of reflection responses with MalformedFiles set:
+// field 1 with a truncated length.
+var MalformedFile = []byte{0x0a, 0xff}
+
+// Config selects the faults to inject.
+type Config struct {
+	// Methods is a regular expression of the full method names, e.g.
+	// /echo3.Echo/Hello, faults are injected into. Empty matches all.
+	Methods string
+	// Latency delays every call and every streamed message.
+	Latency time.Duration
+	// Code fails every call with this status code unless it is OK.
+	Code codes.Code
+	// DropAfter aborts reflection streams after this many responses.
+	DropAfter int
+	// MalformedFiles replaces all file_descriptor_proto bytes of
+	// reflection responses with MalformedFile.
+	MalformedFiles bool
+	// OutOfOrder answers every reflection request but the first with the
+	// response to the previous request.
+	OutOfOrder bool
+}
+
+// Injector injects the faults of its Config with server interceptors.
+type Injector struct {
+	cfg     Config
+	methods *regexp.Regexp
+}
+
+// New returns an Injector for the given Config.
+func New(cfg Config) (*Injector, error) {
+	methods, err := regexp.Compile(cfg.Methods)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot compile methods expression")
+	}
+	return &Injector{cfg: cfg, methods: methods}, nil
+}
+
+// ParseCode parses a gRPC status code given by number or by name, e.g.
+// 14, UNAVAILABLE or unavailable.
+func ParseCode(s string) (codes.Code, error) {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil {
+		return codes.Code(n), nil
+	}
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(s)))); err != nil {
+		return 0, errors.Errorf("invalid status code %s", s)
+	}
+	return code, nil
+}
+
+// ServerOptions returns the interceptors injecting the faults.
+func (in *Injector) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(in.unary),
+		grpc.ChainStreamInterceptor(in.stream),
+	}
+}
+
+func (in *Injector) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if !in.methods.MatchString(info.FullMethod) {
+		return handler(ctx, req)
+	}
+	if err := in.inject(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (in *Injector) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !in.methods.MatchString(info.FullMethod) {
+		return handler(srv, ss)
+	}
+	if err := in.inject(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, &stream{ServerStream: ss, cfg: &in.cfg})
+}
+
+// inject delays the call by the latency and fails it with the code, if
+// set.
+func (in *Injector) inject(ctx context.Context) error {
+	if err := sleep(ctx, in.cfg.Latency); err != nil {
+		return err
+	}
+	if in.cfg.Code != codes.OK {
+		return status.Errorf(in.cfg.Code, "injected fault: %s", in.cfg.Code)
+	}
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// stream delays every sent message by the latency and drops, corrupts or
+// reorders ServerReflectionResponses.
+type stream struct {
+	grpc.ServerStream
+	cfg  *Config
+	sent int
+	prev *rpb.ServerReflectionResponse
+}
+
+func (s *stream) SendMsg(m interface{}) error {
+	if err := sleep(s.Context(), s.cfg.Latency); err != nil {
+		return err
+	}
+	resp, ok := m.(*rpb.ServerReflectionResponse)
+	if !ok {
+		return s.ServerStream.SendMsg(m)
+	}
+	if s.cfg.DropAfter > 0 && s.sent >= s.cfg.DropAfter {
+		return status.Errorf(codes.Aborted, "injected fault: reflection stream dropped after %d responses", s.sent)
+	}
+	if s.cfg.MalformedFiles && resp.GetFileDescriptorResponse() != nil {
+		resp = proto.Clone(resp).(*rpb.ServerReflectionResponse)
+		files := resp.GetFileDescriptorResponse().FileDescriptorProto
+		for i := range files {
+			files[i] = MalformedFile
+		}
+	}
+	if s.cfg.OutOfOrder {
+		cur := resp
+		if s.prev != nil {
+			resp = s.prev
+		}
+		s.prev = cur
+	}
+	s.sent++
+	return s.ServerStream.SendMsg(resp)
+}
@@ -0,0 +1,63 @@
+// Package library contains protoc-generated output of a schema spread
+// across several files and packages, with transitive and public imports,
+// custom options, extensions, nested types and proto2 groups, and
+// implements a test Library service. It is intended for reflect testing
+// only.
+package library
+
+import (
+	"context"
+
+	"github.com/juliaogris/reflect/pkg/library/types"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Server implements the server-side of the Library test service.
+type Server struct {
+	UnimplementedLibraryServer
+}
+
+// Books are the books served by Server, each with an isbn extension.
+func Books() []*types.Book {
+	book := &types.Book{
+		Name: proto.String("The Go Programming Language"),
+		Author: &types.Author{
+			Name:  proto.String("Alan Donovan"),
+			Email: proto.String("alan@example.com"),
+			Address: &types.Author_Address{
+				City:    proto.String("New York"),
+				Country: proto.String("US"),
+			},
+		},
+		Chapter: []*types.Book_Chapter{
+			{Title: proto.String("Tutorial"), Pages: proto.Int32(34)},
+			{Title: proto.String("Program Structure"), Pages: proto.Int32(26)},
+		},
+		Format: types.Book_PAPERBACK.Enum(),
+	}
+	proto.SetExtension(book, E_Isbn, "978-0134190440")
+	return []*types.Book{book}
+}
+
+// GetBook returns the book with the requested name.
+func (*Server) GetBook(_ context.Context, req *GetBookRequest) (*types.Book, error) {
+	for _, book := range Books() {
+		if book.GetName() == req.GetName() {
+			return book, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "unknown book %q", req.GetName())
+}
+
+// ListBooks streams all books.
+func (*Server) ListBooks(_ *ListBooksRequest, stream Library_ListBooksServer) error {
+	for _, book := range Books() {
+		if err := stream.Send(book); err != nil {
+			return errors.Wrap(err, "cannot send on ListBooks")
+		}
+	}
+	return nil
+}
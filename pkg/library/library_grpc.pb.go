@@ -0,0 +1,171 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package library
+
+import (
+	context "context"
+
+	types "github.com/juliaogris/reflect/pkg/library/types"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// LibraryClient is the client API for Library service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LibraryClient interface {
+	// GetBook returns a book by name.
+	GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*types.Book, error)
+	// ListBooks streams all books.
+	ListBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (Library_ListBooksClient, error)
+}
+
+type libraryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLibraryClient(cc grpc.ClientConnInterface) LibraryClient {
+	return &libraryClient{cc}
+}
+
+func (c *libraryClient) GetBook(ctx context.Context, in *GetBookRequest, opts ...grpc.CallOption) (*types.Book, error) {
+	out := new(types.Book)
+	err := c.cc.Invoke(ctx, "/library.Library/GetBook", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *libraryClient) ListBooks(ctx context.Context, in *ListBooksRequest, opts ...grpc.CallOption) (Library_ListBooksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Library_ServiceDesc.Streams[0], "/library.Library/ListBooks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &libraryListBooksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Library_ListBooksClient interface {
+	Recv() (*types.Book, error)
+	grpc.ClientStream
+}
+
+type libraryListBooksClient struct {
+	grpc.ClientStream
+}
+
+func (x *libraryListBooksClient) Recv() (*types.Book, error) {
+	m := new(types.Book)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LibraryServer is the server API for Library service.
+// All implementations must embed UnimplementedLibraryServer
+// for forward compatibility
+type LibraryServer interface {
+	// GetBook returns a book by name.
+	GetBook(context.Context, *GetBookRequest) (*types.Book, error)
+	// ListBooks streams all books.
+	ListBooks(*ListBooksRequest, Library_ListBooksServer) error
+	mustEmbedUnimplementedLibraryServer()
+}
+
+// UnimplementedLibraryServer must be embedded to have forward compatible implementations.
+type UnimplementedLibraryServer struct {
+}
+
+func (UnimplementedLibraryServer) GetBook(context.Context, *GetBookRequest) (*types.Book, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBook not implemented")
+}
+func (UnimplementedLibraryServer) ListBooks(*ListBooksRequest, Library_ListBooksServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListBooks not implemented")
+}
+func (UnimplementedLibraryServer) mustEmbedUnimplementedLibraryServer() {}
+
+// UnsafeLibraryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LibraryServer will
+// result in compilation errors.
+type UnsafeLibraryServer interface {
+	mustEmbedUnimplementedLibraryServer()
+}
+
+func RegisterLibraryServer(s grpc.ServiceRegistrar, srv LibraryServer) {
+	s.RegisterService(&Library_ServiceDesc, srv)
+}
+
+func _Library_GetBook_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBookRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LibraryServer).GetBook(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/library.Library/GetBook",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LibraryServer).GetBook(ctx, req.(*GetBookRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Library_ListBooks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListBooksRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LibraryServer).ListBooks(m, &libraryListBooksServer{stream})
+}
+
+type Library_ListBooksServer interface {
+	Send(*types.Book) error
+	grpc.ServerStream
+}
+
+type libraryListBooksServer struct {
+	grpc.ServerStream
+}
+
+func (x *libraryListBooksServer) Send(m *types.Book) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Library_ServiceDesc is the grpc.ServiceDesc for Library service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Library_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "library.Library",
+	HandlerType: (*LibraryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBook",
+			Handler:    _Library_GetBook_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListBooks",
+			Handler:       _Library_ListBooks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "library/library.proto",
+}
@@ -0,0 +1,516 @@
+//
+// Copyright 2021 Square Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.25.0
+// 	protoc        v3.17.3
+// source: library/types/types.proto
+
+package types
+
+import (
+	reflect "reflect"
+	sync "sync"
+
+	proto "github.com/golang/protobuf/proto"
+	_ "github.com/juliaogris/reflect/pkg/library/options"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoiface "google.golang.org/protobuf/runtime/protoiface"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// This is a compile-time assertion that a sufficiently up-to-date version
+// of the legacy proto package is being used.
+const _ = proto.ProtoPackageIsVersion4
+
+type Book_Format int32
+
+const (
+	Book_PAPERBACK Book_Format = 0
+	Book_HARDCOVER Book_Format = 1
+	Book_EBOOK     Book_Format = 2
+)
+
+// Enum value maps for Book_Format.
+var (
+	Book_Format_name = map[int32]string{
+		0: "PAPERBACK",
+		1: "HARDCOVER",
+		2: "EBOOK",
+	}
+	Book_Format_value = map[string]int32{
+		"PAPERBACK": 0,
+		"HARDCOVER": 1,
+		"EBOOK":     2,
+	}
+)
+
+func (x Book_Format) Enum() *Book_Format {
+	p := new(Book_Format)
+	*p = x
+	return p
+}
+
+func (x Book_Format) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Book_Format) Descriptor() protoreflect.EnumDescriptor {
+	return file_library_types_types_proto_enumTypes[0].Descriptor()
+}
+
+func (Book_Format) Type() protoreflect.EnumType {
+	return &file_library_types_types_proto_enumTypes[0]
+}
+
+func (x Book_Format) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Do not use.
+func (x *Book_Format) UnmarshalJSON(b []byte) error {
+	num, err := protoimpl.X.UnmarshalJSONEnum(x.Descriptor(), b)
+	if err != nil {
+		return err
+	}
+	*x = Book_Format(num)
+	return nil
+}
+
+// Deprecated: Use Book_Format.Descriptor instead.
+func (Book_Format) EnumDescriptor() ([]byte, []int) {
+	return file_library_types_types_proto_rawDescGZIP(), []int{0, 0}
+}
+
+type Book struct {
+	state           protoimpl.MessageState
+	sizeCache       protoimpl.SizeCache
+	unknownFields   protoimpl.UnknownFields
+	extensionFields protoimpl.ExtensionFields
+
+	Name    *string         `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Author  *Author         `protobuf:"bytes,2,opt,name=author" json:"author,omitempty"`
+	Chapter []*Book_Chapter `protobuf:"group,3,rep,name=Chapter,json=chapter" json:"chapter,omitempty"`
+	Format  *Book_Format    `protobuf:"varint,6,opt,name=format,enum=library.types.Book_Format,def=0" json:"format,omitempty"`
+}
+
+// Default values for Book fields.
+const (
+	Default_Book_Format = Book_PAPERBACK
+)
+
+func (x *Book) Reset() {
+	*x = Book{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_library_types_types_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Book) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Book) ProtoMessage() {}
+
+func (x *Book) ProtoReflect() protoreflect.Message {
+	mi := &file_library_types_types_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Book.ProtoReflect.Descriptor instead.
+func (*Book) Descriptor() ([]byte, []int) {
+	return file_library_types_types_proto_rawDescGZIP(), []int{0}
+}
+
+var extRange_Book = []protoiface.ExtensionRangeV1{
+	{Start: 100, End: 199},
+}
+
+// Deprecated: Use Book.ProtoReflect.Descriptor.ExtensionRanges instead.
+func (*Book) ExtensionRangeArray() []protoiface.ExtensionRangeV1 {
+	return extRange_Book
+}
+
+func (x *Book) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *Book) GetAuthor() *Author {
+	if x != nil {
+		return x.Author
+	}
+	return nil
+}
+
+func (x *Book) GetChapter() []*Book_Chapter {
+	if x != nil {
+		return x.Chapter
+	}
+	return nil
+}
+
+func (x *Book) GetFormat() Book_Format {
+	if x != nil && x.Format != nil {
+		return *x.Format
+	}
+	return Default_Book_Format
+}
+
+type Author struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name    *string         `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Email   *string         `protobuf:"bytes,2,opt,name=email" json:"email,omitempty"`
+	Address *Author_Address `protobuf:"bytes,3,opt,name=address" json:"address,omitempty"`
+}
+
+func (x *Author) Reset() {
+	*x = Author{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_library_types_types_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Author) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Author) ProtoMessage() {}
+
+func (x *Author) ProtoReflect() protoreflect.Message {
+	mi := &file_library_types_types_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Author.ProtoReflect.Descriptor instead.
+func (*Author) Descriptor() ([]byte, []int) {
+	return file_library_types_types_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Author) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+func (x *Author) GetEmail() string {
+	if x != nil && x.Email != nil {
+		return *x.Email
+	}
+	return ""
+}
+
+func (x *Author) GetAddress() *Author_Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+type Book_Chapter struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Title *string `protobuf:"bytes,4,opt,name=title" json:"title,omitempty"`
+	Pages *int32  `protobuf:"varint,5,opt,name=pages" json:"pages,omitempty"`
+}
+
+func (x *Book_Chapter) Reset() {
+	*x = Book_Chapter{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_library_types_types_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Book_Chapter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Book_Chapter) ProtoMessage() {}
+
+func (x *Book_Chapter) ProtoReflect() protoreflect.Message {
+	mi := &file_library_types_types_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Book_Chapter.ProtoReflect.Descriptor instead.
+func (*Book_Chapter) Descriptor() ([]byte, []int) {
+	return file_library_types_types_proto_rawDescGZIP(), []int{0, 0}
+}
+
+func (x *Book_Chapter) GetTitle() string {
+	if x != nil && x.Title != nil {
+		return *x.Title
+	}
+	return ""
+}
+
+func (x *Book_Chapter) GetPages() int32 {
+	if x != nil && x.Pages != nil {
+		return *x.Pages
+	}
+	return 0
+}
+
+type Author_Address struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	City    *string `protobuf:"bytes,1,opt,name=city" json:"city,omitempty"`
+	Country *string `protobuf:"bytes,2,opt,name=country" json:"country,omitempty"`
+}
+
+func (x *Author_Address) Reset() {
+	*x = Author_Address{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_library_types_types_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Author_Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Author_Address) ProtoMessage() {}
+
+func (x *Author_Address) ProtoReflect() protoreflect.Message {
+	mi := &file_library_types_types_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Author_Address.ProtoReflect.Descriptor instead.
+func (*Author_Address) Descriptor() ([]byte, []int) {
+	return file_library_types_types_proto_rawDescGZIP(), []int{1, 0}
+}
+
+func (x *Author_Address) GetCity() string {
+	if x != nil && x.City != nil {
+		return *x.City
+	}
+	return ""
+}
+
+func (x *Author_Address) GetCountry() string {
+	if x != nil && x.Country != nil {
+		return *x.Country
+	}
+	return ""
+}
+
+var File_library_types_types_proto protoreflect.FileDescriptor
+
+var file_library_types_types_proto_rawDesc = []byte{
+	0x0a, 0x19, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x2f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2f,
+	0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0d, 0x6c, 0x69, 0x62,
+	0x72, 0x61, 0x72, 0x79, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x1a, 0x1d, 0x6c, 0x69, 0x62, 0x72,
+	0x61, 0x72, 0x79, 0x2f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2f, 0x6f, 0x70, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xb0, 0x02, 0x0a, 0x04, 0x42, 0x6f,
+	0x6f, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x06, 0x61, 0x75, 0x74, 0x68, 0x6f, 0x72,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79,
+	0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x52, 0x06, 0x61,
+	0x75, 0x74, 0x68, 0x6f, 0x72, 0x12, 0x35, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x70, 0x74, 0x65, 0x72,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0a, 0x32, 0x1b, 0x2e, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79,
+	0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x42, 0x6f, 0x6f, 0x6b, 0x2e, 0x43, 0x68, 0x61, 0x70,
+	0x74, 0x65, 0x72, 0x52, 0x07, 0x63, 0x68, 0x61, 0x70, 0x74, 0x65, 0x72, 0x12, 0x3d, 0x0a, 0x06,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1a, 0x2e, 0x6c,
+	0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x42, 0x6f, 0x6f,
+	0x6b, 0x2e, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x3a, 0x09, 0x50, 0x41, 0x50, 0x45, 0x52, 0x42,
+	0x41, 0x43, 0x4b, 0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x1a, 0x35, 0x0a, 0x07, 0x43,
+	0x68, 0x61, 0x70, 0x74, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x12, 0x14, 0x0a, 0x05,
+	0x70, 0x61, 0x67, 0x65, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x70, 0x61, 0x67,
+	0x65, 0x73, 0x22, 0x31, 0x0a, 0x06, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x0d, 0x0a, 0x09,
+	0x50, 0x41, 0x50, 0x45, 0x52, 0x42, 0x41, 0x43, 0x4b, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x48,
+	0x41, 0x52, 0x44, 0x43, 0x4f, 0x56, 0x45, 0x52, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x42,
+	0x4f, 0x4f, 0x4b, 0x10, 0x02, 0x2a, 0x05, 0x08, 0x64, 0x10, 0xc8, 0x01, 0x22, 0xaa, 0x01, 0x0a,
+	0x06, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x05, 0x65,
+	0x6d, 0x61, 0x69, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x42, 0x04, 0x90, 0xb5, 0x18, 0x01,
+	0x52, 0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x12, 0x37, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x6c, 0x69, 0x62, 0x72, 0x61,
+	0x72, 0x79, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x41, 0x75, 0x74, 0x68, 0x6f, 0x72, 0x2e,
+	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x1a, 0x37, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63,
+	0x69, 0x74, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x69, 0x74, 0x79, 0x12,
+	0x18, 0x0a, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x31, 0x5a, 0x2f, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x75, 0x6c, 0x69, 0x61, 0x6f, 0x67, 0x72,
+	0x69, 0x73, 0x2f, 0x72, 0x65, 0x66, 0x6c, 0x65, 0x63, 0x74, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x6c,
+	0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x2f, 0x74, 0x79, 0x70, 0x65, 0x73,
+}
+
+var (
+	file_library_types_types_proto_rawDescOnce sync.Once
+	file_library_types_types_proto_rawDescData = file_library_types_types_proto_rawDesc
+)
+
+func file_library_types_types_proto_rawDescGZIP() []byte {
+	file_library_types_types_proto_rawDescOnce.Do(func() {
+		file_library_types_types_proto_rawDescData = protoimpl.X.CompressGZIP(file_library_types_types_proto_rawDescData)
+	})
+	return file_library_types_types_proto_rawDescData
+}
+
+var file_library_types_types_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_library_types_types_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_library_types_types_proto_goTypes = []interface{}{
+	(Book_Format)(0),       // 0: library.types.Book.Format
+	(*Book)(nil),           // 1: library.types.Book
+	(*Author)(nil),         // 2: library.types.Author
+	(*Book_Chapter)(nil),   // 3: library.types.Book.Chapter
+	(*Author_Address)(nil), // 4: library.types.Author.Address
+}
+var file_library_types_types_proto_depIdxs = []int32{
+	2, // 0: library.types.Book.author:type_name -> library.types.Author
+	3, // 1: library.types.Book.chapter:type_name -> library.types.Book.Chapter
+	0, // 2: library.types.Book.format:type_name -> library.types.Book.Format
+	4, // 3: library.types.Author.address:type_name -> library.types.Author.Address
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_library_types_types_proto_init() }
+func file_library_types_types_proto_init() {
+	if File_library_types_types_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_library_types_types_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Book); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			case 3:
+				return &v.extensionFields
+			default:
+				return nil
+			}
+		}
+		file_library_types_types_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Author); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_library_types_types_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Book_Chapter); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_library_types_types_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Author_Address); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_library_types_types_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_library_types_types_proto_goTypes,
+		DependencyIndexes: file_library_types_types_proto_depIdxs,
+		EnumInfos:         file_library_types_types_proto_enumTypes,
+		MessageInfos:      file_library_types_types_proto_msgTypes,
+	}.Build()
+	File_library_types_types_proto = out.File
+	file_library_types_types_proto_rawDesc = nil
+	file_library_types_types_proto_goTypes = nil
+	file_library_types_types_proto_depIdxs = nil
+}
@@ -0,0 +1,272 @@
+// Package reflectclient is a client for the gRPC server reflection
+// service. A Client sends all requests over a single, long-lived
+// ServerReflectionInfo stream and decodes the responses.
+package reflectclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Client is a reflection client safe for concurrent use. Requests are
+// serialized on the stream, which is opened on first use and reopened
+// after an error.
+type Client struct {
+	conn    *grpc.ClientConn
+	ownConn bool
+	host    string
+
+	mu     sync.Mutex
+	stream rpb.ServerReflection_ServerReflectionInfoClient
+	cancel context.CancelFunc
+}
+
+// Option configures a Client.
+type Option func(*options)
+
+type options struct {
+	dialOpts []grpc.DialOption
+	host     string
+}
+
+// WithDialOptions adds gRPC dial options used by Dial.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) { o.dialOpts = append(o.dialOpts, opts...) }
+}
+
+// WithInsecure dials without transport security.
+func WithInsecure() Option {
+	return WithDialOptions(grpc.WithInsecure())
+}
+
+// WithTransportCredentials dials with the given credentials, e.g. TLS.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return WithDialOptions(grpc.WithTransportCredentials(creds))
+}
+
+// WithAuthority overrides the :authority pseudo-header of all calls.
+func WithAuthority(authority string) Option {
+	return WithDialOptions(grpc.WithAuthority(authority))
+}
+
+// WithHost sets the host field of the requests sent by the typed
+// methods. Send leaves requests unchanged.
+func WithHost(host string) Option {
+	return func(o *options) { o.host = host }
+}
+
+// Dial connects to the reflection server at target. Close closes the
+// connection.
+func Dial(target string, opts ...Option) (*Client, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	conn, err := grpc.Dial(target, o.dialOpts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot grpc dial %s", target)
+	}
+	return &Client{conn: conn, ownConn: true, host: o.host}, nil
+}
+
+// New returns a Client using conn, which Close leaves open. Dial options
+// are ignored.
+func New(conn *grpc.ClientConn, opts ...Option) *Client {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &Client{conn: conn, host: o.host}
+}
+
+// Conn returns the connection of the client.
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
+// Close closes the stream and, if created by Dial, the connection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeStream()
+	if c.ownConn {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// closeTimeout bounds how long closeStream waits for the server to end
+// the stream before cancelling it.
+var closeTimeout = time.Second
+
+func (c *Client) closeStream() {
+	if c.stream == nil {
+		return
+	}
+	timer := time.AfterFunc(closeTimeout, c.cancel)
+	closeAndDrain(c.stream)
+	timer.Stop()
+	c.cancel()
+	c.stream = nil
+}
+
+// Send sends req unchanged and returns the response, which may be an
+// error_response.
+func (c *Client) Send(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stream == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := rpb.NewServerReflectionClient(c.conn).ServerReflectionInfo(ctx)
+		if err != nil {
+			cancel()
+			return nil, errors.Wrap(err, "cannot setup reflection stream")
+		}
+		c.stream, c.cancel = stream, cancel
+	}
+	resp, err := send(c.stream, req)
+	if err != nil {
+		// The stream is broken or out of sync, start over next time.
+		c.cancel()
+		c.stream = nil
+	}
+	return resp, err
+}
+
+// ResponseError is the error_response of a reflection request.
+type ResponseError struct {
+	Code    codes.Code
+	Message string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("reflection error %d: %s", e.Code, e.Message)
+}
+
+// ListServices returns the full names of all services of the server.
+func (c *Client) ListServices() ([]string, error) {
+	resp, err := c.do(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	services := resp.GetListServicesResponse().GetService()
+	names := make([]string, len(services))
+	for i, service := range services {
+		names[i] = service.GetName()
+	}
+	return names, nil
+}
+
+// FileContainingSymbol returns the file defining the fully qualified
+// symbol followed by its dependencies not yet sent on the stream.
+func (c *Client) FileContainingSymbol(symbol string) ([]*dpb.FileDescriptorProto, error) {
+	return c.files(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{
+			FileContainingSymbol: symbol,
+		},
+	})
+}
+
+// FileByFilename returns the file with the given path followed by its
+// dependencies not yet sent on the stream.
+func (c *Client) FileByFilename(filename string) ([]*dpb.FileDescriptorProto, error) {
+	return c.files(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{
+			FileByFilename: filename,
+		},
+	})
+}
+
+// FileContainingExtension returns the file defining extension number of
+// message type typ followed by its dependencies not yet sent on the
+// stream.
+func (c *Client) FileContainingExtension(typ string, number int32) ([]*dpb.FileDescriptorProto, error) {
+	return c.files(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_FileContainingExtension{
+			FileContainingExtension: &rpb.ExtensionRequest{
+				ContainingType:  typ,
+				ExtensionNumber: number,
+			},
+		},
+	})
+}
+
+// AllExtensionNumbersOfType returns the numbers of all extensions of
+// message type typ known to the server.
+func (c *Client) AllExtensionNumbersOfType(typ string) ([]int32, error) {
+	resp, err := c.do(&rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_AllExtensionNumbersOfType{
+			AllExtensionNumbersOfType: typ,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetAllExtensionNumbersResponse().GetExtensionNumber(), nil
+}
+
+func (c *Client) files(req *rpb.ServerReflectionRequest) ([]*dpb.FileDescriptorProto, error) {
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	raw := resp.GetFileDescriptorResponse().GetFileDescriptorProto()
+	fdps := make([]*dpb.FileDescriptorProto, len(raw))
+	for i, b := range raw {
+		fdps[i] = &dpb.FileDescriptorProto{}
+		if err := proto.Unmarshal(b, fdps[i]); err != nil {
+			return nil, errors.Wrap(err, "cannot decode file descriptor")
+		}
+	}
+	return fdps, nil
+}
+
+// do sends a copy of req with the client's host and returns
+// error_responses as ResponseError.
+func (c *Client) do(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	req = proto.Clone(req).(*rpb.ServerReflectionRequest)
+	req.Host = c.host
+	resp, err := c.Send(req)
+	if err != nil {
+		return nil, err
+	}
+	if e := resp.GetErrorResponse(); e != nil {
+		return nil, &ResponseError{Code: codes.Code(e.GetErrorCode()), Message: e.GetErrorMessage()}
+	}
+	return resp, nil
+}
+
+func send(stream rpb.ServerReflection_ServerReflectionInfoClient, req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := stream.Send(req); err != nil {
+		return nil, errors.Wrap(err, "cannot send reflection request")
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot receive reflection response")
+	}
+	if orig := resp.GetOriginalRequest(); orig != nil && !proto.Equal(orig, req) {
+		return nil, errors.Errorf("reflection response out of order: got response to %v", orig)
+	}
+	return resp, nil
+}
+
+func closeAndDrain(stream rpb.ServerReflection_ServerReflectionInfoClient) {
+	_ = stream.CloseSend()
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package reflectclient
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/juliaogris/reflect/pkg/echo3"
+	"github.com/juliaogris/reflect/pkg/faults"
+	"github.com/juliaogris/reflect/pkg/library"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+)
+
+// startServer starts a gRPC server with reflection and the services
+// registered by register and returns it with its address.
+func startServer(t *testing.T, register func(grpc.ServiceRegistrar), opts ...grpc.ServerOption) (*grpc.Server, string) {
+	t.Helper()
+	server := grpc.NewServer(opts...)
+	register(server)
+	reflection.Register(server)
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(lis) }()
+	return server, lis.Addr().String()
+}
+
+func registerServices(s grpc.ServiceRegistrar) {
+	echo3.RegisterEchoServer(s, &echo3.Server{})
+	library.RegisterLibraryServer(s, &library.Server{})
+}
+
+func TestClient(t *testing.T) {
+	server, addr := startServer(t, registerServices)
+	defer server.Stop()
+	c, err := Dial(addr, WithInsecure(), WithHost("example.com"))
+	require.NoError(t, err)
+	defer c.Close()
+
+	services, err := c.ListServices()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"echo3.Echo", "library.Library", "grpc.reflection.v1alpha.ServerReflection"}, services)
+
+	fdps, err := c.FileContainingSymbol("library.Library")
+	require.NoError(t, err)
+	require.Equal(t, "library/library.proto", fdps[0].GetName())
+	require.Len(t, fdps, 5)
+
+	fdps, err = c.FileByFilename("library/types/types.proto")
+	require.NoError(t, err)
+	require.Len(t, fdps, 1)
+	require.Equal(t, "Book", fdps[0].GetMessageType()[0].GetName())
+
+	fdps, err = c.FileContainingExtension("library.types.Book", 100)
+	require.NoError(t, err)
+	require.Equal(t, "library/library.proto", fdps[0].GetName())
+
+	numbers, err := c.AllExtensionNumbersOfType("library.types.Book")
+	require.NoError(t, err)
+	require.Equal(t, []int32{100}, numbers)
+
+	_, err = c.FileContainingSymbol("MISSING")
+	require.Equal(t, codes.NotFound, errors.Cause(err).(*ResponseError).Code)
+
+	resp, err := c.Send(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_ListServices{}})
+	require.NoError(t, err)
+	require.Empty(t, resp.GetOriginalRequest().GetHost())
+}
+
+func TestClientReopensStream(t *testing.T) {
+	injector, err := faults.New(faults.Config{DropAfter: 1})
+	require.NoError(t, err)
+	server, addr := startServer(t, registerServices, injector.ServerOptions()...)
+	defer server.Stop()
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	c := New(conn)
+
+	_, err = c.ListServices()
+	require.NoError(t, err)
+	_, err = c.ListServices()
+	require.Equal(t, codes.Aborted, status.Code(errors.Cause(err)))
+	_, err = c.ListServices()
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+}
+
+type fakeStream struct {
+	rpb.ServerReflection_ServerReflectionInfoClient
+	sendErr error
+	sent    []*rpb.ServerReflectionRequest
+	resps   []*rpb.ServerReflectionResponse
+	closed  bool
+}
+
+func (f *fakeStream) Send(req *rpb.ServerReflectionRequest) error {
+	f.sent = append(f.sent, req)
+	return f.sendErr
+}
+
+func (f *fakeStream) CloseSend() error {
+	f.closed = true
+	return io.ErrClosedPipe
+}
+
+func (f *fakeStream) Recv() (*rpb.ServerReflectionResponse, error) {
+	if len(f.resps) == 0 {
+		return nil, io.EOF
+	}
+	resp := f.resps[0]
+	f.resps = f.resps[1:]
+	return resp, nil
+}
+
+func TestSendErr(t *testing.T) {
+	req := &rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_ListServices{}}
+	_, err := send(&fakeStream{sendErr: io.ErrClosedPipe}, req)
+	require.Error(t, err)
+	require.Equal(t, "cannot send reflection request: io: read/write on closed pipe", err.Error())
+
+	_, err = send(&fakeStream{}, req)
+	require.Error(t, err)
+	require.Equal(t, "cannot receive reflection response: EOF", err.Error())
+
+	// Responses without original request are accepted.
+	resp, err := send(&fakeStream{resps: []*rpb.ServerReflectionResponse{{ValidHost: "h"}}}, req)
+	require.NoError(t, err)
+	require.Equal(t, "h", resp.ValidHost)
+}
+
+func TestCloseAndDrain(t *testing.T) {
+	stream := &fakeStream{resps: []*rpb.ServerReflectionResponse{{}, {}}}
+	closeAndDrain(stream)
+	require.True(t, stream.closed)
+	require.Empty(t, stream.resps)
+}
+
+func TestDoCopiesRequest(t *testing.T) {
+	stream := &fakeStream{resps: []*rpb.ServerReflectionResponse{{}}}
+	c := &Client{host: "example.com", stream: stream, cancel: func() {}}
+	req := &rpb.ServerReflectionRequest{Host: "orig", MessageRequest: &rpb.ServerReflectionRequest_ListServices{}}
+	_, err := c.do(req)
+	require.NoError(t, err)
+	require.Equal(t, "orig", req.Host)
+	require.Equal(t, "example.com", stream.sent[0].Host)
+}
+
+// blockingStream does not end after CloseSend until it is cancelled.
+type blockingStream struct {
+	fakeStream
+	done chan struct{}
+}
+
+func (b *blockingStream) Recv() (*rpb.ServerReflectionResponse, error) {
+	<-b.done
+	return nil, io.EOF
+}
+
+func TestCloseCancelsStream(t *testing.T) {
+	defer func(d time.Duration) { closeTimeout = d }(closeTimeout)
+	closeTimeout = time.Millisecond
+	stream := &blockingStream{done: make(chan struct{})}
+	c := &Client{stream: stream, cancel: func() {
+		select {
+		case <-stream.done:
+		default:
+			close(stream.done)
+		}
+	}}
+	require.NoError(t, c.Close())
+	require.True(t, stream.closed)
+	require.Nil(t, c.stream)
+}
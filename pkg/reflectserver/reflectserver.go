@@ -0,0 +1,188 @@
+// Package reflectserver implements the gRPC reflection service for the
+// files and types of descriptor registries in addition to those linked
+// into the binary, which are all the reflection package knows about. It
+// is intended for reflect testing only.
+package reflectserver
+
+import (
+	"io"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Server serves reflection for the services registered with the gRPC
+// server and for additional services, files and types, e.g. those of a
+// protoset. Files and types linked into the binary are used as fallback.
+type Server struct {
+	rpb.UnimplementedServerReflectionServer
+	server   *grpc.Server
+	files    *protoregistry.Files
+	types    *protoregistry.Types
+	services []string
+}
+
+// Register registers a reflection Server for the given services, files
+// and types with s.
+func Register(s *grpc.Server, files *protoregistry.Files, types *protoregistry.Types, services []string) {
+	rpb.RegisterServerReflectionServer(s, &Server{server: s, files: files, types: types, services: services})
+}
+
+// ServerReflectionInfo answers the reflection requests of a stream.
+func (r *Server) ServerReflectionInfo(stream rpb.ServerReflection_ServerReflectionInfoServer) error {
+	sent := map[string]bool{}
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resp := &rpb.ServerReflectionResponse{
+			ValidHost:       req.GetHost(),
+			OriginalRequest: req,
+		}
+		switch mr := req.MessageRequest.(type) {
+		case *rpb.ServerReflectionRequest_ListServices:
+			resp.MessageResponse = r.listServices()
+		case *rpb.ServerReflectionRequest_FileByFilename:
+			fd, err := r.findFile(mr.FileByFilename)
+			setFiles(resp, fd, err, sent)
+		case *rpb.ServerReflectionRequest_FileContainingSymbol:
+			var fd protoreflect.FileDescriptor
+			d, err := r.findDescriptor(protoreflect.FullName(mr.FileContainingSymbol))
+			if err == nil {
+				fd = d.ParentFile()
+			}
+			setFiles(resp, fd, err, sent)
+		case *rpb.ServerReflectionRequest_FileContainingExtension:
+			var fd protoreflect.FileDescriptor
+			ext := mr.FileContainingExtension
+			xt, err := r.findExtension(protoreflect.FullName(ext.GetContainingType()), protoreflect.FieldNumber(ext.GetExtensionNumber()))
+			if err == nil {
+				fd = xt.TypeDescriptor().ParentFile()
+			}
+			setFiles(resp, fd, err, sent)
+		case *rpb.ServerReflectionRequest_AllExtensionNumbersOfType:
+			r.setExtensionNumbers(resp, protoreflect.FullName(mr.AllExtensionNumbersOfType))
+		default:
+			setError(resp, codes.InvalidArgument, "invalid MessageRequest")
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Server) listServices() *rpb.ServerReflectionResponse_ListServicesResponse {
+	names := map[string]bool{}
+	for name := range r.server.GetServiceInfo() {
+		names[name] = true
+	}
+	for _, name := range r.services {
+		names[name] = true
+	}
+	services := make([]*rpb.ServiceResponse, 0, len(names))
+	for name := range names {
+		services = append(services, &rpb.ServiceResponse{Name: name})
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return &rpb.ServerReflectionResponse_ListServicesResponse{
+		ListServicesResponse: &rpb.ListServiceResponse{Service: services},
+	}
+}
+
+func (r *Server) findFile(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r *Server) findDescriptor(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+func (r *Server) findExtension(message protoreflect.FullName, number protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	if xt, err := r.types.FindExtensionByNumber(message, number); err == nil {
+		return xt, nil
+	}
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, number)
+}
+
+func (r *Server) setExtensionNumbers(resp *rpb.ServerReflectionResponse, message protoreflect.FullName) {
+	if _, err := r.findDescriptor(message); err != nil {
+		setError(resp, codes.NotFound, err.Error())
+		return
+	}
+	seen := map[protoreflect.FieldNumber]bool{}
+	var numbers []int32
+	add := func(xt protoreflect.ExtensionType) bool {
+		if n := xt.TypeDescriptor().Number(); !seen[n] {
+			seen[n] = true
+			numbers = append(numbers, int32(n))
+		}
+		return true
+	}
+	r.types.RangeExtensionsByMessage(message, add)
+	protoregistry.GlobalTypes.RangeExtensionsByMessage(message, add)
+	resp.MessageResponse = &rpb.ServerReflectionResponse_AllExtensionNumbersResponse{
+		AllExtensionNumbersResponse: &rpb.ExtensionNumberResponse{
+			BaseTypeName:    string(message),
+			ExtensionNumber: numbers,
+		},
+	}
+}
+
+// setFiles sets fd and all its transitive dependencies that have not yet
+// been sent on the stream as file descriptor response. Like the
+// reflection package it always includes fd, as the first file.
+func setFiles(resp *rpb.ServerReflectionResponse, fd protoreflect.FileDescriptor, err error, sent map[string]bool) {
+	if err != nil {
+		setError(resp, codes.NotFound, err.Error())
+		return
+	}
+	var files [][]byte
+	var add func(fd protoreflect.FileDescriptor) error
+	add = func(fd protoreflect.FileDescriptor) error {
+		if sent[fd.Path()] && len(files) != 0 {
+			return nil
+		}
+		b, err := proto.Marshal(protodesc.ToFileDescriptorProto(fd))
+		if err != nil {
+			return err
+		}
+		sent[fd.Path()] = true
+		files = append(files, b)
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			if err := add(imports.Get(i).FileDescriptor); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := add(fd); err != nil {
+		setError(resp, codes.Internal, err.Error())
+		return
+	}
+	resp.MessageResponse = &rpb.ServerReflectionResponse_FileDescriptorResponse{
+		FileDescriptorResponse: &rpb.FileDescriptorResponse{FileDescriptorProto: files},
+	}
+}
+
+func setError(resp *rpb.ServerReflectionResponse, code codes.Code, msg string) {
+	resp.MessageResponse = &rpb.ServerReflectionResponse_ErrorResponse{
+		ErrorResponse: &rpb.ErrorResponse{ErrorCode: int32(code), ErrorMessage: msg},
+	}
+}
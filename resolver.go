@@ -0,0 +1,261 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/juliaogris/reflect/pkg/dynamictypes"
+	"github.com/juliaogris/reflect/pkg/reflectclient"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+// resolver looks up the message and extension types needed to expand
+// google.protobuf.Any payloads and extension fields when decoding and
+// printing messages.
+type resolver interface {
+	protoregistry.ExtensionTypeResolver
+	protoregistry.MessageTypeResolver
+}
+
+// fetcher retrieves FileDescriptorProtos, e.g. from a reflection server.
+// Close releases its connection, if any.
+type fetcher interface {
+	fileContainingSymbol(symbol string) ([]*dpb.FileDescriptorProto, error)
+	fileContainingExtension(typ string, number int32) ([]*dpb.FileDescriptorProto, error)
+	fileByFilename(filename string) ([]*dpb.FileDescriptorProto, error)
+	io.Closer
+}
+
+// typeRegistry is a resolver backed by files added from a protoset or
+// fetched on demand. Types linked into the binary take precedence.
+type typeRegistry struct {
+	files *protoregistry.Files
+	types *protoregistry.Types
+	fetch fetcher // nil if files cannot be fetched on demand
+}
+
+func newResolver(g globals) (resolver, error) {
+	switch g.AnyResolver {
+	case "server":
+		if g.Address == "" {
+			return protoregistry.GlobalTypes, nil
+		}
+		return newTypeRegistry(withCache(&serverFetcher{g: g}, g)), nil
+	case "protoset":
+		return newProtosetResolver(g.Protoset)
+	case "none", "":
+		return protoregistry.GlobalTypes, nil
+	}
+	return nil, errors.Errorf("unknown any resolver %s", g.AnyResolver)
+}
+
+func newProtosetResolver(filename string) (resolver, error) {
+	if filename == "" {
+		return nil, errors.New("--protoset required for protoset any resolver")
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read protoset")
+	}
+	fds := &dpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, fds); err != nil {
+		return nil, errors.Wrap(err, "cannot decode protoset")
+	}
+	r := newTypeRegistry(nil)
+	if err := r.addFiles(fds.File); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func newTypeRegistry(f fetcher) *typeRegistry {
+	return &typeRegistry{
+		files: &protoregistry.Files{},
+		types: &protoregistry.Types{},
+		fetch: f,
+	}
+}
+
+// Close closes the connection of the fetcher, if any.
+func (r *typeRegistry) Close() error {
+	if r.fetch == nil {
+		return nil
+	}
+	return r.fetch.Close()
+}
+
+func (r *typeRegistry) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	if mt, err := protoregistry.GlobalTypes.FindMessageByName(name); err == nil {
+		return mt, nil
+	}
+	if mt, err := r.types.FindMessageByName(name); err == nil {
+		return mt, nil
+	}
+	if err := r.fetchSymbol(string(name)); err != nil {
+		return nil, err
+	}
+	return r.types.FindMessageByName(name)
+}
+
+func (r *typeRegistry) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	name := url
+	if i := strings.LastIndexByte(url, '/'); i >= 0 {
+		name = url[i+1:]
+	}
+	return r.FindMessageByName(protoreflect.FullName(name))
+}
+
+func (r *typeRegistry) FindExtensionByName(name protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	if xt, err := protoregistry.GlobalTypes.FindExtensionByName(name); err == nil {
+		return xt, nil
+	}
+	if xt, err := r.types.FindExtensionByName(name); err == nil {
+		return xt, nil
+	}
+	if err := r.fetchSymbol(string(name)); err != nil {
+		return nil, err
+	}
+	return r.types.FindExtensionByName(name)
+}
+
+func (r *typeRegistry) FindExtensionByNumber(message protoreflect.FullName, number protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	if xt, err := protoregistry.GlobalTypes.FindExtensionByNumber(message, number); err == nil {
+		return xt, nil
+	}
+	if xt, err := r.types.FindExtensionByNumber(message, number); err == nil {
+		return xt, nil
+	}
+	if r.fetch == nil {
+		return nil, protoregistry.NotFound
+	}
+	fdps, err := r.fetch.fileContainingExtension(string(message), int32(number))
+	if err != nil {
+		return nil, protoregistry.NotFound
+	}
+	if err := r.addFiles(fdps); err != nil {
+		return nil, err
+	}
+	return r.types.FindExtensionByNumber(message, number)
+}
+
+func (r *typeRegistry) fetchSymbol(symbol string) error {
+	if r.fetch == nil {
+		return protoregistry.NotFound
+	}
+	fdps, err := r.fetch.fileContainingSymbol(symbol)
+	if err != nil {
+		return protoregistry.NotFound
+	}
+	return r.addFiles(fdps)
+}
+
+// addFiles registers the given files and their types. The files may be
+// in any order; dependencies missing from fdps are fetched if possible
+// or otherwise taken from the files linked into the binary.
+func (r *typeRegistry) addFiles(fdps []*dpb.FileDescriptorProto) error {
+	byName := make(map[string]*dpb.FileDescriptorProto, len(fdps))
+	for _, fdp := range fdps {
+		byName[fdp.GetName()] = fdp
+	}
+	for _, fdp := range fdps {
+		if err := r.addFile(fdp.GetName(), byName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *typeRegistry) addFile(name string, byName map[string]*dpb.FileDescriptorProto) error {
+	if _, err := r.files.FindFileByPath(name); err == nil {
+		return nil
+	}
+	fdp, ok := byName[name]
+	if !ok {
+		return r.addMissingFile(name)
+	}
+	for _, dep := range fdp.GetDependency() {
+		if err := r.addFile(dep, byName); err != nil {
+			return err
+		}
+	}
+	fd, err := protodesc.NewFile(fdp, r.files)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create file descriptor for %s", name)
+	}
+	if err := r.files.RegisterFile(fd); err != nil {
+		return errors.Wrapf(err, "cannot register file %s", name)
+	}
+	return dynamictypes.Register(r.types, fd)
+}
+
+func (r *typeRegistry) addMissingFile(name string) error {
+	if r.fetch != nil {
+		fdps, err := r.fetch.fileByFilename(name)
+		if err == nil {
+			return r.addFiles(fdps)
+		}
+	}
+	fd, err := protoregistry.GlobalFiles.FindFileByPath(name)
+	if err != nil {
+		return errors.Errorf("cannot find dependency %s", name)
+	}
+	return errors.Wrapf(r.files.RegisterFile(fd), "cannot register file %s", name)
+}
+
+// serverFetcher fetches files from the reflection server at g.Address
+// with a single client, which is created lazily unless given and closed
+// by Close.
+type serverFetcher struct {
+	g      globals
+	client *reflectclient.Client
+}
+
+func (s *serverFetcher) fileContainingSymbol(symbol string) ([]*dpb.FileDescriptorProto, error) {
+	c, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return c.FileContainingSymbol(symbol)
+}
+
+func (s *serverFetcher) fileContainingExtension(typ string, number int32) ([]*dpb.FileDescriptorProto, error) {
+	c, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return c.FileContainingExtension(typ, number)
+}
+
+func (s *serverFetcher) fileByFilename(filename string) ([]*dpb.FileDescriptorProto, error) {
+	c, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	return c.FileByFilename(filename)
+}
+
+func (s *serverFetcher) connect() (*reflectclient.Client, error) {
+	if s.client == nil {
+		c, err := newClient(s.g)
+		if err != nil {
+			return nil, err
+		}
+		s.client = c
+	}
+	return s.client, nil
+}
+
+func (s *serverFetcher) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	err := s.client.Close()
+	s.client = nil
+	return err
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// printTemplate executes the Go template text with the message m as data.
+// Fields are accessed by their Go names, e.g.
+// {{range .ListServicesResponse.Service}}{{.Name}}{{"\n"}}{{end}}.
+func printTemplate(m protoreflect.ProtoMessage, text string, g globals) error {
+	tmpl, err := template.New("output").Parse(text)
+	if err != nil {
+		return errors.Wrap(err, "cannot parse template")
+	}
+	err = tmpl.Execute(g.out, templateData(m.ProtoReflect()))
+	return errors.Wrap(err, "cannot execute template")
+}
+
+// templateData converts m into maps keyed by Go field names. Unset message
+// fields are empty maps so that templates can reach into them; they are
+// not descended into, which would never end for recursive types.
+func templateData(m protoreflect.Message) map[string]interface{} {
+	data := map[string]interface{}{}
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Message() != nil && fd.Cardinality() != protoreflect.Repeated && !m.Has(fd) {
+			data[goName(fd.Name())] = map[string]interface{}{}
+			continue
+		}
+		data[goName(fd.Name())] = templateValue(fd, m.Get(fd))
+	}
+	return data
+}
+
+func templateValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch {
+	case fd.IsList():
+		l := v.List()
+		list := make([]interface{}, l.Len())
+		for i := range list {
+			list[i] = singularValue(fd, l.Get(i))
+		}
+		return list
+	case fd.IsMap():
+		mp := map[string]interface{}{}
+		v.Map().Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			mp[k.String()] = singularValue(fd.MapValue(), v)
+			return true
+		})
+		return mp
+	}
+	return singularValue(fd, v)
+}
+
+func singularValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return templateData(v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	}
+	return v.Interface()
+}
+
+// goName converts a proto field name to its Go name, e.g.
+// list_services_response to ListServicesResponse.
+func goName(name protoreflect.Name) string {
+	parts := strings.Split(string(name), "_")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// printField prints the values at the dot separated path of proto field
+// names, e.g. list_services_response.service.name, one per line. Repeated
+// fields along the path select all their elements. Message values are
+// printed in the output format, each followed by a newline for the text
+// based formats json, text and base64.
+func printField(m protoreflect.ProtoMessage, path string, g globals) error {
+	values, err := selectField(m.ProtoReflect(), strings.Split(path, "."))
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		if v.fd.Message() != nil {
+			if err := printMessage(v.val.Message().Interface(), g); err != nil {
+				return err
+			}
+			if err := endMessage(g); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintln(g.out, scalarString(v.fd, v.val)); err != nil {
+			return errors.Wrap(err, "cannot print field")
+		}
+	}
+	return nil
+}
+
+// endMessage prints a newline after a message printed in one of the text
+// based formats json, text and base64, which do not end in one, so that
+// several messages can be printed one after another.
+func endMessage(g globals) error {
+	if g.Format != "json" && g.Format != "text" && g.Format != "base64" {
+		return nil
+	}
+	_, err := fmt.Fprintln(g.out)
+	return errors.Wrap(err, "cannot print newline")
+}
+
+type fieldValue struct {
+	fd  protoreflect.FieldDescriptor
+	val protoreflect.Value
+}
+
+func selectField(m protoreflect.Message, path []string) ([]fieldValue, error) {
+	fd := m.Descriptor().Fields().ByName(protoreflect.Name(path[0]))
+	if fd == nil {
+		fd = m.Descriptor().Fields().ByJSONName(path[0])
+	}
+	if fd == nil {
+		return nil, errors.Errorf("unknown field %s in %s", path[0], m.Descriptor().FullName())
+	}
+	if fd.IsMap() {
+		return nil, errors.Errorf("cannot select map field %s", fd.Name())
+	}
+	var values []protoreflect.Value
+	switch {
+	case fd.IsList():
+		l := m.Get(fd).List()
+		for i := 0; i < l.Len(); i++ {
+			values = append(values, l.Get(i))
+		}
+	case fd.Message() == nil || m.Has(fd):
+		values = append(values, m.Get(fd))
+	}
+	if len(path) == 1 {
+		result := make([]fieldValue, len(values))
+		for i, v := range values {
+			result[i] = fieldValue{fd: fd, val: v}
+		}
+		return result, nil
+	}
+	if fd.Message() == nil {
+		return nil, errors.Errorf("cannot select %s in scalar field %s", path[1], fd.Name())
+	}
+	if len(values) == 0 {
+		// validate the rest of the path against an empty message
+		empty := m.NewField(fd)
+		if fd.IsList() {
+			empty = empty.List().NewElement()
+		}
+		_, err := selectField(empty.Message(), path[1:])
+		return nil, err
+	}
+	var result []fieldValue
+	for _, v := range values {
+		sub, err := selectField(v.Message(), path[1:])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, sub...)
+	}
+	return result, nil
+}
+
+func scalarString(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	}
+	return v.String()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+// stabilize returns a copy of m without server specific or randomly
+// ordered content: valid_host is cleared and services, extension numbers
+// and the files of a FileDescriptorSet are sorted.
+func stabilize(m protoreflect.ProtoMessage) protoreflect.ProtoMessage {
+	m = proto.Clone(m)
+	switch m := m.(type) {
+	case *rpb.ServerReflectionResponse:
+		m.ValidHost = ""
+		if services := m.GetListServicesResponse().GetService(); services != nil {
+			sort.Slice(services, func(i, j int) bool { return services[i].GetName() < services[j].GetName() })
+		}
+		if numbers := m.GetAllExtensionNumbersResponse().GetExtensionNumber(); numbers != nil {
+			sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+		}
+	case *dpb.FileDescriptorSet:
+		sort.Slice(m.File, func(i, j int) bool { return m.File[i].GetName() < m.File[j].GetName() })
+	}
+	return m
+}
+
+// normalizeJSON replaces the randomized whitespace of protojson output
+// with a two space indent.
+func normalizeJSON(b []byte) ([]byte, error) {
+	out := &bytes.Buffer{}
+	if err := json.Indent(out, b, "", "  "); err != nil {
+		return nil, errors.Wrap(err, "cannot normalize JSON")
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// normalizeText removes the randomized extra spaces prototext inserts
+// between fields, leaving string literals unchanged.
+func normalizeText(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	inString, escaped := false, false
+	for i, c := range b {
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ' ' && i > 0 && b[i-1] == ' ':
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	channelzpb "google.golang.org/grpc/channelz/grpc_channelz_v1"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	dpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+// printTable prints listings such as services, extensions and files as
+// aligned columns. Details not contained in m, e.g. the number of methods
+// of a service, are looked up on the server.
+func printTable(m protoreflect.ProtoMessage, g globals) error {
+	var headers []string
+	var rows [][]string
+	var err error
+	switch m := m.(type) {
+	case *rpb.ServerReflectionResponse:
+		headers, rows, err = responseTable(m, g)
+	case *dpb.FileDescriptorSet:
+		headers, rows = fileTable(m.File, g.Wide)
+	case *dpb.FileDescriptorProto:
+		headers, rows = fileTable([]*dpb.FileDescriptorProto{m}, g.Wide)
+	case *channelzpb.GetServersResponse:
+		headers, rows = channelzServerTable(m)
+	case *channelzpb.GetTopChannelsResponse:
+		headers, rows = channelzChannelTable(m)
+	case *healthpb.HealthCheckResponse:
+		headers, rows = []string{"STATUS"}, [][]string{{m.GetStatus().String()}}
+	default:
+		err = errors.Errorf("cannot print %s as table", proto.MessageName(m))
+	}
+	if err != nil {
+		return err
+	}
+	if g.NoHeaders {
+		headers = nil
+	}
+	return printRows(g.out, headers, rows)
+}
+
+func responseTable(resp *rpb.ServerReflectionResponse, g globals) ([]string, [][]string, error) {
+	switch mr := resp.MessageResponse.(type) {
+	case *rpb.ServerReflectionResponse_ListServicesResponse:
+		var names []string
+		for _, s := range mr.ListServicesResponse.GetService() {
+			names = append(names, s.GetName())
+		}
+		r, closeRegistry := g.descriptorRegistry()
+		defer closeRegistry()
+		return serviceTable(r, names, g.Wide)
+	case *rpb.ServerReflectionResponse_AllExtensionNumbersResponse:
+		r, closeRegistry := g.descriptorRegistry()
+		defer closeRegistry()
+		base := mr.AllExtensionNumbersResponse.GetBaseTypeName()
+		numbers := mr.AllExtensionNumbersResponse.GetExtensionNumber()
+		return extensionTable(r, base, numbers, g.Wide)
+	case *rpb.ServerReflectionResponse_FileDescriptorResponse:
+		var fdps []*dpb.FileDescriptorProto
+		for _, b := range mr.FileDescriptorResponse.GetFileDescriptorProto() {
+			fdp := &dpb.FileDescriptorProto{}
+			if err := unmarshal(b, fdp, g); err != nil {
+				return nil, nil, errors.Wrap(err, "cannot decode file descriptor")
+			}
+			fdps = append(fdps, fdp)
+		}
+		headers, rows := fileTable(fdps, g.Wide)
+		return headers, rows, nil
+	case *rpb.ServerReflectionResponse_ErrorResponse:
+		headers := []string{"CODE", "MESSAGE"}
+		rows := [][]string{{strconv.Itoa(int(mr.ErrorResponse.GetErrorCode())), mr.ErrorResponse.GetErrorMessage()}}
+		return headers, rows, nil
+	}
+	return nil, nil, errors.New("cannot print empty response as table")
+}
+
+func serviceTable(r *typeRegistry, names []string, wide bool) ([]string, [][]string, error) {
+	headers := []string{"SERVICE", "METHODS", "FILE"}
+	if wide {
+		headers = append(headers, "PACKAGE", "SYNTAX")
+	}
+	rows := make([][]string, 0, len(names))
+	for _, name := range names {
+		d, err := r.findDescriptor(protoreflect.FullName(name))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot find service %s", name)
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, nil, errors.Errorf("%s is not a service", name)
+		}
+		f := sd.ParentFile()
+		row := []string{name, strconv.Itoa(sd.Methods().Len()), f.Path()}
+		if wide {
+			row = append(row, string(f.Package()), f.Syntax().String())
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows, nil
+}
+
+// extensionTable resolves the extension numbers of the base type and
+// returns them sorted by number.
+func extensionTable(r *typeRegistry, base string, numbers []int32, wide bool) ([]string, [][]string, error) {
+	headers := []string{"NUMBER", "NAME", "TYPE", "FILE"}
+	if wide {
+		headers = append(headers, "LABEL", "PACKAGE")
+	}
+	numbers = append([]int32(nil), numbers...)
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+	rows := make([][]string, 0, len(numbers))
+	for _, n := range numbers {
+		xt, err := r.FindExtensionByNumber(protoreflect.FullName(base), protoreflect.FieldNumber(n))
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "cannot resolve extension %d", n)
+		}
+		xd := xt.TypeDescriptor()
+		f := xd.ParentFile()
+		row := []string{strconv.Itoa(int(n)), string(xd.FullName()), fieldType(xd), f.Path()}
+		if wide {
+			row = append(row, xd.Cardinality().String(), string(f.Package()))
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows, nil
+}
+
+// fieldType returns the message or enum name of a field or its scalar
+// kind, e.g. HttpRule or string.
+func fieldType(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.Message() != nil:
+		return string(fd.Message().Name())
+	case fd.Enum() != nil:
+		return string(fd.Enum().Name())
+	}
+	return fd.Kind().String()
+}
+
+func fileTable(fdps []*dpb.FileDescriptorProto, wide bool) ([]string, [][]string) {
+	headers := []string{"FILE", "PACKAGE", "MESSAGES", "ENUMS", "SERVICES"}
+	if wide {
+		headers = append(headers, "SYNTAX", "DEPENDENCIES")
+	}
+	rows := make([][]string, 0, len(fdps))
+	for _, fdp := range fdps {
+		row := []string{
+			fdp.GetName(),
+			fdp.GetPackage(),
+			strconv.Itoa(len(fdp.GetMessageType())),
+			strconv.Itoa(len(fdp.GetEnumType())),
+			strconv.Itoa(len(fdp.GetService())),
+		}
+		if wide {
+			syntax := fdp.GetSyntax()
+			if syntax == "" {
+				syntax = "proto2"
+			}
+			row = append(row, syntax, strings.Join(fdp.GetDependency(), ","))
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows
+}
+
+func printRows(w io.Writer, headers []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	if headers != nil {
+		fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return errors.Wrap(tw.Flush(), "cannot print table")
+}
+
+// descriptorRegistry returns the typeRegistry of the any resolver if
+// there is one, or a new registry fetching from the server. The returned
+// func closes the registry if it is new.
+func (g globals) descriptorRegistry() (*typeRegistry, func()) {
+	if r, ok := g.resolver.(*typeRegistry); ok {
+		return r, func() {}
+	}
+	r := newTypeRegistry(nil)
+	if g.Address != "" {
+		r = newTypeRegistry(withCache(&serverFetcher{g: g}, g))
+	}
+	return r, func() { _ = r.Close() }
+}
+
+// findDescriptor looks up a descriptor by full name in the registry,
+// fetching its file if needed. Without a fetcher it falls back to the
+// files linked into the binary.
+func (r *typeRegistry) findDescriptor(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	if r.fetch == nil {
+		return protoregistry.GlobalFiles.FindDescriptorByName(name)
+	}
+	fdps, err := r.fetch.fileContainingSymbol(string(name))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.addFiles(fdps); err != nil {
+		return nil, err
+	}
+	return r.files.FindDescriptorByName(name)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// runner is implemented by all reflect commands.
+type runner interface {
+	Run(g globals) error
+}
+
+// multiRunner returns cmd as runner if it queries a server and can run
+// against several servers. The output of each server is buffered until
+// its command returns, so commands that run until interrupted, such as
+// watch and health --watch, are rejected, as is raw reading its request
+// from stdin, which can only be read once.
+func multiRunner(cmd interface{}) (runner, bool) {
+	switch cmd := cmd.(type) {
+	case *servicesCmd, *symbolCmd, *filenameCmd, *extensionCmd, *extensionsCmd,
+		*channelzServersCmd, *channelzChannelsCmd, *channelzSocketsCmd:
+		return cmd.(runner), true
+	case *rawCmd:
+		return cmd, cmd.Request != "" || cmd.In != "-"
+	case *healthCmd:
+		return cmd, !cmd.Watch
+	}
+	return nil, false
+}
+
+// localCmd reports whether cmd works without a server. Local commands run
+// once, however many servers are given.
+func localCmd(cmd interface{}) bool {
+	switch cmd.(type) {
+	case *decodeDescriptorCmd, *convertCmd, *fdCmd, *fdsCmd, *fdsfCmd, *cacheLsCmd, *cacheClearCmd:
+		return true
+	}
+	return false
+}
+
+// readTargets returns the non-empty lines of filename that are not
+// comments starting with #.
+func readTargets(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open targets file")
+	}
+	defer f.Close()
+	var targets []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			targets = append(targets, line)
+		}
+	}
+	return targets, errors.Wrap(scanner.Err(), "cannot read targets file")
+}
+
+type targetResult struct {
+	out         bytes.Buffer
+	err         error
+	fingerprint string // schema fingerprint, only set for backends
+}
+
+// runTargets runs cmd against every target with at most concurrency
+// targets in flight. The output of each target is printed in the order of
+// targets under a "==> target <==" header; per-target errors are printed
+// in place of the output.
+func runTargets(cmd runner, g globals, targets []string, concurrency int) error {
+	results := runConcurrently(targets, concurrency, func(target string, r *targetResult) {
+		tg := g
+		tg.Address = target
+		tg.hostAddress = target
+		tg.out = &r.out
+		if r.err = tg.setResolver(); r.err == nil {
+			r.err = cmd.Run(tg)
+			tg.closeResolver()
+		}
+	})
+
+	failed := 0
+	for i, target := range targets {
+		if results[i].err != nil {
+			failed++
+		}
+		if err := printResult(g.out, target, &results[i]); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("%d of %d targets failed", failed, len(targets))
+	}
+	return nil
+}
+
+// printResult prints the output of r, or its error, under a
+// "==> header <==" line.
+func printResult(w io.Writer, header string, r *targetResult) error {
+	fmt.Fprintf(w, "==> %s <==\n", header)
+	b := r.out.Bytes()
+	if r.err != nil {
+		b = []byte(fmt.Sprintf("error: %v\n", r.err))
+	}
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		b = append(b, '\n')
+	}
+	_, err := w.Write(b)
+	return errors.Wrap(err, "cannot print target output")
+}
+
+// runConcurrently calls f for every target with at most concurrency calls
+// in flight and returns the results in the order of targets.
+func runConcurrently(targets []string, concurrency int, f func(target string, r *targetResult)) []targetResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]targetResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(r *targetResult, target string) {
+			defer func() { <-sem; wg.Done() }()
+			f(target, r)
+		}(&results[i], target)
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+// transportCredentials returns TLS credentials verifying the server with
+// --cacert or the system roots and presenting --cert for mutual TLS.
+func (g globals) transportCredentials() (credentials.TransportCredentials, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if g.CACert != "" {
+		b, err := os.ReadFile(g.CACert)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read CA certificate")
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(b) {
+			return nil, errors.Errorf("no certificates in %s", g.CACert)
+		}
+	}
+	if (g.Cert == "") != (g.Key == "") {
+		return nil, errors.New("--cert and --key must be given together")
+	}
+	if g.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(g.Cert, g.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot load client certificate")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(cfg), nil
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+type watchCmd struct {
+	Interval time.Duration `help:"polling interval" default:"5s"`
+	Exec     string        `help:"shell command to run on every change, with the event as JSON on stdin"`
+	Count    int           `help:"stop after this many polls, 0 for no limit"`
+}
+
+// schema is the full resolved schema of a server: its services and all
+// files defining them, including transitive dependencies.
+type schema struct {
+	services []string
+	files    map[string][]byte // file name to deterministic serialization
+}
+
+// watchEvent is printed as a JSON line for the initial schema, every
+// change and every failed poll.
+type watchEvent struct {
+	Time                time.Time `json:"time"`
+	Event               string    `json:"event"` // initial, changed or error
+	Fingerprint         string    `json:"fingerprint,omitempty"`
+	PreviousFingerprint string    `json:"previousFingerprint,omitempty"`
+	ServicesAdded       []string  `json:"servicesAdded,omitempty"`
+	ServicesRemoved     []string  `json:"servicesRemoved,omitempty"`
+	FilesAdded          []string  `json:"filesAdded,omitempty"`
+	FilesRemoved        []string  `json:"filesRemoved,omitempty"`
+	FilesChanged        []string  `json:"filesChanged,omitempty"`
+	Error               string    `json:"error,omitempty"`
+}
+
+func (w *watchCmd) Run(g globals) error {
+	var prev *schema
+	for i := 0; w.Count == 0 || i < w.Count; i++ {
+		if i > 0 {
+			time.Sleep(w.Interval)
+		}
+		s, err := fetchSchema(g)
+		var event *watchEvent
+		switch {
+		case err != nil:
+			event = &watchEvent{Event: "error", Error: err.Error()}
+		case prev == nil:
+			event = &watchEvent{Event: "initial", Fingerprint: s.fingerprint()}
+		case s.fingerprint() != prev.fingerprint():
+			event = diffSchemas(prev, s)
+		}
+		if err == nil {
+			prev = s
+		}
+		if event == nil {
+			continue
+		}
+		event.Time = time.Now().UTC()
+		if err := w.emit(event, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *watchCmd) emit(event *watchEvent, g globals) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "cannot encode watch event")
+	}
+	b = append(b, '\n')
+	if _, err := g.out.Write(b); err != nil {
+		return errors.Wrap(err, "cannot print watch event")
+	}
+	if w.Exec == "" || event.Event != "changed" {
+		return nil
+	}
+	cmd := exec.Command("sh", "-c", w.Exec)
+	cmd.Stdin = bytes.NewReader(b)
+	cmd.Stdout = g.out
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "REFLECT_FINGERPRINT="+event.Fingerprint)
+	// A failing hook is reported but does not stop watching.
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "cannot run watch hook: %v\n", err)
+	}
+	return nil
+}
+
+// fetchSchema lists the services of the server and fetches the files
+// containing them on a single stream, which yields every file once.
+func fetchSchema(g globals) (*schema, error) {
+	c, err := newClient(g)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	services, err := c.ListServices()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot list services")
+	}
+	s := &schema{services: services, files: map[string][]byte{}}
+	for _, service := range services {
+		fdps, err := c.FileContainingSymbol(service)
+		if err != nil {
+			return nil, err
+		}
+		for _, fdp := range fdps {
+			b, err := proto.MarshalOptions{Deterministic: true}.Marshal(fdp)
+			if err != nil {
+				return nil, errors.Wrap(err, "cannot encode file descriptor")
+			}
+			s.files[fdp.GetName()] = b
+		}
+	}
+	sort.Strings(s.services)
+	return s, nil
+}
+
+// fingerprint is the hex SHA-256 of the service names and the names and
+// contents of all files.
+func (s *schema) fingerprint() string {
+	h := sha256.New()
+	for _, service := range s.services {
+		h.Write([]byte(service))
+		h.Write([]byte{0})
+	}
+	for _, name := range sortedKeys(s.files) {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write(s.files[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func diffSchemas(prev, cur *schema) *watchEvent {
+	event := &watchEvent{
+		Event:               "changed",
+		Fingerprint:         cur.fingerprint(),
+		PreviousFingerprint: prev.fingerprint(),
+	}
+	event.ServicesAdded, event.ServicesRemoved = diffStrings(prev.services, cur.services)
+	event.FilesAdded, event.FilesRemoved = diffStrings(sortedKeys(prev.files), sortedKeys(cur.files))
+	for _, name := range sortedKeys(cur.files) {
+		if b, ok := prev.files[name]; ok && !bytes.Equal(b, cur.files[name]) {
+			event.FilesChanged = append(event.FilesChanged, name)
+		}
+	}
+	return event
+}
+
+// diffStrings returns the elements only in b and only in a.
+func diffStrings(a, b []string) (added, removed []string) {
+	inA := map[string]bool{}
+	for _, s := range a {
+		inA[s] = true
+	}
+	inB := map[string]bool{}
+	for _, s := range b {
+		inB[s] = true
+		if !inA[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range a {
+		if !inB[s] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}